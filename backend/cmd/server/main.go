@@ -8,10 +8,16 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Timezone names in profiles are validated even on hosts without zoneinfo
 
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/blobstore"
 	"mock-orbit/backend/internal/config"
 	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/handlers"
+	"mock-orbit/backend/internal/mailer"
 	"mock-orbit/backend/internal/routes"
+	"mock-orbit/backend/internal/throttle"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -39,6 +45,43 @@ func main() {
 	}
 	defer database.DisconnectDB()
 
+	// Configure outgoing mail
+	mailer.Setup()
+
+	// Choose where failed login counters are kept
+	throttle.Setup()
+
+	// Choose where uploaded files are stored
+	blobstore.Setup()
+
+	// Load (or create) the JWT signing keys and keep them rotating
+	keyCtx, cancelKeys := context.WithCancel(context.Background())
+	defer cancelKeys()
+	if err := auth.InitKeyRing(keyCtx); err != nil {
+		log.Fatalf("Failed to initialize signing keys: %v", err)
+	}
+	auth.StartKeyRotation(keyCtx)
+
+	// Grant the admin role to the accounts listed in ADMIN_EMAILS
+	if err := handlers.BootstrapAdmins(context.Background()); err != nil {
+		log.Printf("Error bootstrapping admin accounts: %v", err)
+	}
+
+	// Make users created before peer search findable
+	if err := handlers.BackfillUserSearchKeys(context.Background()); err != nil {
+		log.Printf("Error backfilling user search keys: %v", err)
+	}
+
+	// Purge accounts whose deletion grace period has passed
+	purgeCtx, cancelPurge := context.WithCancel(context.Background())
+	defer cancelPurge()
+	handlers.StartAccountPurge(purgeCtx)
+
+	// Copy renamed users' names into their interviews
+	nameSyncCtx, cancelNameSync := context.WithCancel(context.Background())
+	defer cancelNameSync()
+	handlers.StartNameSync(nameSyncCtx)
+
 	// Set Gin mode (ReleaseMode, DebugMode, TestMode)
 	gin.SetMode(gin.DebugMode) // Use DebugMode for development logging
 
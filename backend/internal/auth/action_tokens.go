@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Purposes of signed action tokens. The purpose is carried in the "typ" claim,
+// so a token minted for one flow can never be replayed in another (or used as
+// an access token).
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+	PurposeMFAPending        = "mfa_pending"
+	PurposeOIDCLogin         = "oidc_login"
+	PurposeGuestJoin         = "guest_join"
+	PurposeEmailChange       = "email_change"
+	PurposeEmailRevert       = "email_revert"
+)
+
+// ErrTokenUsed is returned when a single-use action token is redeemed twice.
+var ErrTokenUsed = errors.New("token has already been used")
+
+// NewActionToken signs a token for a one-off action (such as confirming an
+// email address) on behalf of a user. Extra claims are merged in as-is.
+func NewActionToken(purpose string, userID primitive.ObjectID, ttl time.Duration, extra map[string]interface{}) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	claims["typ"] = purpose
+	claims["jti"] = tokenID
+	claims["user_id"] = userID.Hex()
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+
+	return signClaims(claims)
+}
+
+// ParseActionToken validates an action token for the given purpose and returns its claims.
+func ParseActionToken(purpose, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if err := parseClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if typ, _ := claims["typ"].(string); typ != purpose {
+		return nil, ErrInvalidToken
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ConsumeActionToken marks an action token as used. It returns ErrTokenUsed if
+// the token was already redeemed. Entries expire with the token itself.
+func ConsumeActionToken(ctx context.Context, claims jwt.MapClaims) error {
+	tokenID, _ := claims["jti"].(string)
+	purpose, _ := claims["typ"].(string)
+	exp, _ := claims["exp"].(float64)
+	_, err := database.GetCollection("used_action_tokens").InsertOne(ctx, bson.M{
+		"_id":        tokenID,
+		"purpose":    purpose,
+		"expires_at": time.Unix(int64(exp), 0).UTC(),
+		"used_at":    time.Now().UTC(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTokenUsed
+	}
+	return err
+}
+
+// PasswordFingerprint returns a short digest of a stored password hash. Embedding
+// it in a reset token makes the token stop working once the password changes.
+func PasswordFingerprint(passwordHash string) string {
+	return HashToken(passwordHash)[:16]
+}
+
+// ClaimObjectID reads a hex ObjectID claim such as "user_id".
+func ClaimObjectID(claims jwt.MapClaims, key string) (primitive.ObjectID, error) {
+	hex, _ := claims[key].(string)
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return id, nil
+}
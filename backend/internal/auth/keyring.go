@@ -0,0 +1,453 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// How often each replica reloads the shared key ring and checks whether a
+	// new signing key is due.
+	keyRingRefreshInterval = time.Minute
+	// Minimum gap between reloads triggered by tokens with an unknown kid.
+	keyRingMissReloadInterval = 10 * time.Second
+
+	// JWKSMaxAge is how long clients may cache the JWKS.
+	JWKSMaxAge = 5 * time.Minute
+	// The next key is generated once the newest one is this close to
+	// retiring. It is published right away, giving verifiers several JWKS
+	// cache lifetimes to pick it up before tokens carry its kid.
+	keyPublishLead = 3 * JWKSMaxAge
+)
+
+var errNoSigningKey = errors.New("auth: no active signing key")
+
+// ringKey is a loaded signing key. Keys are published as soon as they are
+// created and the newest key past its ActivatesAt signs; every unexpired key
+// verifies, so tokens outlive the rotation that replaced their key.
+type ringKey struct {
+	ID          string
+	Alg         string
+	Private     crypto.Signer
+	Public      crypto.PublicKey
+	CreatedAt   time.Time
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// keyRing caches the keys stored in the signing_keys collection, which all
+// replicas share.
+type keyRing struct {
+	mu           sync.RWMutex
+	keys         map[string]*ringKey
+	ordered      []*ringKey // Oldest first
+	lastMissLoad time.Time
+}
+
+var ring = &keyRing{keys: map[string]*ringKey{}}
+
+// InitKeyRing loads the signing keys and creates the first one if none exist.
+// It must be called after the database is connected and before serving requests.
+func InitKeyRing(ctx context.Context) error {
+	if err := ring.reload(ctx); err != nil {
+		return err
+	}
+	return ring.rotateIfDue(ctx)
+}
+
+// StartKeyRotation keeps the key ring in sync with the database and generates
+// the next signing key ahead of the current one's retirement.
+func StartKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyRingRefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ring.reload(ctx); err != nil {
+					log.Printf("Error reloading signing keys: %v", err)
+					continue
+				}
+				if err := ring.rotateIfDue(ctx); err != nil {
+					log.Printf("Error rotating signing key: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// reload replaces the cached keys with the unexpired keys in the database.
+func (r *keyRing) reload(ctx context.Context) error {
+	now := time.Now().UTC()
+	cursor, err := database.GetCollection("signing_keys").Find(ctx,
+		bson.M{"expires_at": bson.M{"$gt": now}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	var stored []models.SigningKey
+	if err := cursor.All(ctx, &stored); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*ringKey, len(stored))
+	ordered := make([]*ringKey, 0, len(stored))
+	for _, sk := range stored {
+		key, err := decodeSigningKey(sk)
+		if err != nil {
+			log.Printf("Skipping unreadable signing key %s: %v", sk.ID, err)
+			continue
+		}
+		keys[key.ID] = key
+		ordered = append(ordered, key)
+		if sk.EncryptedPrivateKey == nil && config.AppConfig.JWTKeyEncryptionKey != nil {
+			encryptStoredKey(ctx, sk)
+		}
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.ordered = ordered
+	r.mu.Unlock()
+	return nil
+}
+
+// rotateIfDue generates the next signing key when nextKeyActivation says one
+// is due.
+func (r *keyRing) rotateIfDue(ctx context.Context) error {
+	r.mu.RLock()
+	var newest *ringKey
+	if len(r.ordered) > 0 {
+		newest = r.ordered[len(r.ordered)-1]
+	}
+	r.mu.RUnlock()
+
+	now := time.Now().UTC()
+	activatesAt, due := nextKeyActivation(newest, now)
+	if !due {
+		return nil
+	}
+
+	cfg := config.AppConfig
+	stored, err := generateSigningKey(cfg.JWTSigningAlg, now, activatesAt, cfg.JWTKeyRotationInterval, cfg.JWTKeyVerificationGrace)
+	if err != nil {
+		return err
+	}
+	if _, err := database.GetCollection("signing_keys").InsertOne(ctx, stored); err != nil {
+		return err
+	}
+	log.Printf("Generated new %s signing key %s (signs from %s, retires %s)", stored.Algorithm, stored.ID,
+		stored.ActivatesAt.Format(time.RFC3339), stored.RetiresAt.Format(time.RFC3339))
+	return r.reload(ctx)
+}
+
+// nextKeyActivation reports whether a key should be generated after newest,
+// the most recently created key, and when it should start signing. Without
+// any key the new one signs immediately. Otherwise it is generated
+// keyPublishLead before newest retires and takes over when it does, but never
+// sooner than JWKSMaxAge from now, so that a JWKS fetched before the key
+// existed has expired from caches by the time tokens carry its kid.
+func nextKeyActivation(newest *ringKey, now time.Time) (time.Time, bool) {
+	if newest == nil {
+		return now, true
+	}
+	if newest.RetiresAt.Sub(now) > keyPublishLead {
+		return time.Time{}, false
+	}
+	if earliest := now.Add(JWKSMaxAge); newest.RetiresAt.Before(earliest) {
+		return earliest, true
+	}
+	return newest.RetiresAt, true
+}
+
+// signingKey returns the key new tokens are signed with: the newest key that
+// has activated. A retired key keeps signing until its successor activates.
+func (r *keyRing) signingKey() (*ringKey, error) {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.ordered) - 1; i >= 0; i-- {
+		if !now.Before(r.ordered[i].ActivatesAt) {
+			return r.ordered[i], nil
+		}
+	}
+	return nil, errNoSigningKey
+}
+
+// verificationKey returns the key with the given kid. Unknown kids trigger a
+// (rate-limited) reload in case another replica just rotated.
+func (r *keyRing) verificationKey(kid string) (*ringKey, bool) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	r.mu.RUnlock()
+	if ok {
+		return key, true
+	}
+
+	r.mu.Lock()
+	if time.Since(r.lastMissLoad) < keyRingMissReloadInterval {
+		r.mu.Unlock()
+		return nil, false
+	}
+	r.lastMissLoad = time.Now()
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.reload(ctx); err != nil {
+		log.Printf("Error reloading signing keys for kid %q: %v", kid, err)
+		return nil, false
+	}
+	r.mu.RLock()
+	key, ok = r.keys[kid]
+	r.mu.RUnlock()
+	return key, ok
+}
+
+// signClaims signs claims with the current key, setting iss and the kid header.
+func signClaims(claims jwt.MapClaims) (string, error) {
+	key, err := ring.signingKey()
+	if err != nil {
+		return "", err
+	}
+	claims["iss"] = config.AppConfig.PublicURL
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Alg), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// parseClaims verifies a token's signature against the key ring and its
+// standard time claims. It is the single verifier for every token type.
+func parseClaims(tokenString string, claims jwt.MapClaims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ring.verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		// The algorithm is pinned by the key, never taken from the token alone
+		if token.Method.Alg() != key.Alg {
+			return nil, fmt.Errorf("unexpected signing method %q for key %s", token.Method.Alg(), kid)
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return ErrExpiredToken
+		}
+		return ErrInvalidToken
+	}
+	if !token.Valid {
+		return ErrInvalidToken
+	}
+	if iss, _ := claims["iss"].(string); iss != config.AppConfig.PublicURL {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set (RFC 7517).
+func JWKS() map[string]interface{} {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	keys := make([]map[string]string, 0, len(ring.keys))
+	for _, key := range ring.keys {
+		jwk := map[string]string{"kid": key.ID, "use": "sig", "alg": key.Alg}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// generateSigningKey creates a key pair for the algorithm ("RS256" or "EdDSA")
+// that signs from activatesAt for the rotation interval.
+func generateSigningKey(alg string, now, activatesAt time.Time, rotation, grace time.Duration) (models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return models.SigningKey{}, fmt.Errorf("auth: unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	kid, err := newTokenID()
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	sk := models.SigningKey{
+		ID:          kid,
+		Algorithm:   alg,
+		CreatedAt:   now,
+		ActivatesAt: activatesAt,
+		RetiresAt:   activatesAt.Add(rotation),
+		ExpiresAt:   activatesAt.Add(rotation + grace),
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if encryptionKey := config.AppConfig.JWTKeyEncryptionKey; encryptionKey != nil {
+		sk.EncryptedPrivateKey, err = sealPrivateKey(encryptionKey, kid, privatePEM)
+		if err != nil {
+			return models.SigningKey{}, err
+		}
+	} else {
+		sk.PrivateKeyPEM = string(privatePEM)
+	}
+	return sk, nil
+}
+
+func decodeSigningKey(sk models.SigningKey) (*ringKey, error) {
+	privatePEM := []byte(sk.PrivateKeyPEM)
+	if sk.EncryptedPrivateKey != nil {
+		var err error
+		if privatePEM, err = openPrivateKey(config.AppConfig.JWTKeyEncryptionKey, sk.ID, sk.EncryptedPrivateKey); err != nil {
+			return nil, err
+		}
+	}
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if sk.Algorithm != "RS256" {
+			return nil, fmt.Errorf("RSA key with algorithm %q", sk.Algorithm)
+		}
+	case ed25519.PrivateKey:
+		if sk.Algorithm != "EdDSA" {
+			return nil, fmt.Errorf("Ed25519 key with algorithm %q", sk.Algorithm)
+		}
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return &ringKey{
+		ID:          sk.ID,
+		Alg:         sk.Algorithm,
+		Private:     private,
+		Public:      private.Public(),
+		CreatedAt:   sk.CreatedAt,
+		ActivatesAt: sk.ActivatesAt,
+		RetiresAt:   sk.RetiresAt,
+		ExpiresAt:   sk.ExpiresAt,
+	}, nil
+}
+
+// sealPrivateKey encrypts a private key PEM with AES-256-GCM. The kid is
+// authenticated along with it, so a sealed key can't be moved to another
+// record.
+func sealPrivateKey(encryptionKey []byte, kid string, privatePEM []byte) ([]byte, error) {
+	gcm, err := keyCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, privatePEM, []byte(kid)), nil
+}
+
+// openPrivateKey decrypts a key sealed by sealPrivateKey.
+func openPrivateKey(encryptionKey []byte, kid string, sealed []byte) ([]byte, error) {
+	if encryptionKey == nil {
+		return nil, errors.New("key is encrypted but JWT_KEY_ENCRYPTION_KEY is not set")
+	}
+	gcm, err := keyCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted key too short")
+	}
+	privatePEM, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(kid))
+	if err != nil {
+		return nil, errors.New("cannot decrypt key; JWT_KEY_ENCRYPTION_KEY may have changed")
+	}
+	return privatePEM, nil
+}
+
+func keyCipher(encryptionKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptStoredKey replaces a key stored in plain text, from before
+// JWT_KEY_ENCRYPTION_KEY was set, with its encrypted form.
+func encryptStoredKey(ctx context.Context, sk models.SigningKey) {
+	sealed, err := sealPrivateKey(config.AppConfig.JWTKeyEncryptionKey, sk.ID, []byte(sk.PrivateKeyPEM))
+	if err != nil {
+		log.Printf("Error encrypting signing key %s: %v", sk.ID, err)
+		return
+	}
+	_, err = database.GetCollection("signing_keys").UpdateOne(ctx,
+		bson.M{"_id": sk.ID, "private_key_pem": sk.PrivateKeyPEM},
+		bson.M{
+			"$set":   bson.M{"encrypted_private_key": sealed},
+			"$unset": bson.M{"private_key_pem": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Error encrypting signing key %s: %v", sk.ID, err)
+		return
+	}
+	log.Printf("Encrypted stored signing key %s", sk.ID)
+}
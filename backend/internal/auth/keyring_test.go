@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// useTestConfig installs a config for the test and restores the previous one after it.
+func useTestConfig(t *testing.T, encryptionKey []byte) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{
+		PublicURL:           "http://api.test",
+		AccessTokenTTL:      15 * time.Minute,
+		JWTKeyEncryptionKey: encryptionKey,
+	}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
+
+// useTestRing replaces the shared key ring with the given keys, oldest first.
+func useTestRing(t *testing.T, keys ...*ringKey) {
+	t.Helper()
+	previous := ring
+	ring = &keyRing{keys: map[string]*ringKey{}, ordered: keys}
+	for _, key := range keys {
+		ring.keys[key.ID] = key
+	}
+	t.Cleanup(func() { ring = previous })
+}
+
+func newTestKey(t *testing.T, alg string, activatesAt time.Time) *ringKey {
+	t.Helper()
+	sk, err := generateSigningKey(alg, activatesAt, activatesAt, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("generateSigningKey(%s): %v", alg, err)
+	}
+	key, err := decodeSigningKey(sk)
+	if err != nil {
+		t.Fatalf("decodeSigningKey(%s): %v", alg, err)
+	}
+	return key
+}
+
+func TestNextKeyActivation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		newest     *ringKey
+		due        bool
+		activation time.Time
+	}{
+		{"no key signs immediately", nil, true, now},
+		{"far from retiring", &ringKey{RetiresAt: now.Add(keyPublishLead + time.Second)}, false, time.Time{}},
+		{"within lead takes over at retirement", &ringKey{RetiresAt: now.Add(keyPublishLead)}, true, now.Add(keyPublishLead)},
+		{"retiring soon waits for JWKS caches", &ringKey{RetiresAt: now.Add(time.Minute)}, true, now.Add(JWKSMaxAge)},
+		{"already retired waits for JWKS caches", &ringKey{RetiresAt: now.Add(-time.Hour)}, true, now.Add(JWKSMaxAge)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activation, due := nextKeyActivation(tt.newest, now)
+			if due != tt.due || !activation.Equal(tt.activation) {
+				t.Errorf("nextKeyActivation() = %s, %v; want %s, %v", activation, due, tt.activation, tt.due)
+			}
+		})
+	}
+}
+
+func TestSigningKeyStorage(t *testing.T) {
+	encryptionKey := bytes.Repeat([]byte{7}, 32)
+	now := time.Now().UTC()
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		for _, encrypted := range []bool{false, true} {
+			name := alg + " plain"
+			if encrypted {
+				name = alg + " encrypted"
+			}
+			t.Run(name, func(t *testing.T) {
+				if encrypted {
+					useTestConfig(t, encryptionKey)
+				} else {
+					useTestConfig(t, nil)
+				}
+				sk, err := generateSigningKey(alg, now, now.Add(JWKSMaxAge), time.Hour, time.Minute)
+				if err != nil {
+					t.Fatalf("generateSigningKey: %v", err)
+				}
+				if encrypted != (sk.EncryptedPrivateKey != nil) || encrypted == (sk.PrivateKeyPEM != "") {
+					t.Fatalf("encrypted = %v but stored PEM %q and %d encrypted bytes", encrypted, sk.PrivateKeyPEM, len(sk.EncryptedPrivateKey))
+				}
+				if !sk.ActivatesAt.Equal(now.Add(JWKSMaxAge)) || !sk.RetiresAt.Equal(sk.ActivatesAt.Add(time.Hour)) || !sk.ExpiresAt.Equal(sk.RetiresAt.Add(time.Minute)) {
+					t.Errorf("unexpected schedule: activates %s, retires %s, expires %s", sk.ActivatesAt, sk.RetiresAt, sk.ExpiresAt)
+				}
+
+				key, err := decodeSigningKey(sk)
+				if err != nil {
+					t.Fatalf("decodeSigningKey: %v", err)
+				}
+				switch key.Public.(type) {
+				case *rsa.PublicKey:
+					if alg != "RS256" {
+						t.Errorf("RSA key for %s", alg)
+					}
+				case ed25519.PublicKey:
+					if alg != "EdDSA" {
+						t.Errorf("Ed25519 key for %s", alg)
+					}
+				default:
+					t.Errorf("unexpected public key type %T", key.Public)
+				}
+			})
+		}
+	}
+}
+
+func TestEncryptedSigningKeyRejectsWrongKeyOrRecord(t *testing.T) {
+	useTestConfig(t, bytes.Repeat([]byte{1}, 32))
+	now := time.Now().UTC()
+	sk, err := generateSigningKey("EdDSA", now, now, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("generateSigningKey: %v", err)
+	}
+
+	moved := sk
+	moved.ID = "another-kid"
+	if _, err := decodeSigningKey(moved); err == nil {
+		t.Error("decoded a sealed key under another kid")
+	}
+
+	config.AppConfig.JWTKeyEncryptionKey = bytes.Repeat([]byte{2}, 32)
+	if _, err := decodeSigningKey(sk); err == nil {
+		t.Error("decoded a sealed key with the wrong encryption key")
+	}
+
+	config.AppConfig.JWTKeyEncryptionKey = nil
+	if _, err := decodeSigningKey(sk); err == nil {
+		t.Error("decoded a sealed key without an encryption key")
+	}
+}
+
+func TestSigningKeyWaitsForActivation(t *testing.T) {
+	useTestConfig(t, nil)
+	now := time.Now().UTC()
+	active := newTestKey(t, "EdDSA", now.Add(-time.Hour))
+	pending := newTestKey(t, "EdDSA", now.Add(JWKSMaxAge))
+	useTestRing(t, active, pending)
+
+	key, err := ring.signingKey()
+	if err != nil || key.ID != active.ID {
+		t.Fatalf("signingKey() = %v, %v; want the active key while the next one is pending", key, err)
+	}
+	if jwks := JWKS()["keys"].([]map[string]string); len(jwks) != 2 {
+		t.Errorf("JWKS has %d keys, want the pending key published too", len(jwks))
+	}
+
+	pending.ActivatesAt = now.Add(-time.Second)
+	if key, err := ring.signingKey(); err != nil || key.ID != pending.ID {
+		t.Errorf("signingKey() = %v, %v; want the newly activated key", key, err)
+	}
+
+	useTestRing(t, pending)
+	pending.ActivatesAt = now.Add(time.Minute)
+	if _, err := ring.signingKey(); err != errNoSigningKey {
+		t.Errorf("signingKey() error = %v, want errNoSigningKey with only a pending key", err)
+	}
+}
+
+func TestSignAndParseClaims(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			useTestConfig(t, nil)
+			key := newTestKey(t, alg, time.Now().Add(-time.Minute))
+			useTestRing(t, key)
+
+			signed, err := signClaims(jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Minute).Unix()})
+			if err != nil {
+				t.Fatalf("signClaims: %v", err)
+			}
+			claims := jwt.MapClaims{}
+			if err := parseClaims(signed, claims); err != nil {
+				t.Fatalf("parseClaims: %v", err)
+			}
+			if claims["sub"] != "user" || claims["iss"] != config.AppConfig.PublicURL {
+				t.Errorf("unexpected claims %v", claims)
+			}
+
+			expired, err := signClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+			if err != nil {
+				t.Fatalf("signClaims: %v", err)
+			}
+			if err := parseClaims(expired, jwt.MapClaims{}); err != ErrExpiredToken {
+				t.Errorf("parseClaims(expired) = %v, want ErrExpiredToken", err)
+			}
+
+			config.AppConfig.PublicURL = "http://elsewhere.test"
+			if err := parseClaims(signed, jwt.MapClaims{}); err != ErrInvalidToken {
+				t.Errorf("parseClaims(other issuer) = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PATPrefix starts every personal access token, which makes them easy to tell
+// apart from JWTs and to spot in leaked logs or commits.
+const PATPrefix = "mo_pat_"
+
+// PATScopes are the scopes a personal access token can be granted. Each names
+// a route group and an access level; write implies read.
+var PATScopes = []string{
+	"users:read",
+	"users:write",
+	"interviews:read",
+	"interviews:write",
+	"catalog:read",
+}
+
+// IsValidPATScope reports whether scope is one of PATScopes.
+func IsValidPATScope(scope string) bool {
+	for _, s := range PATScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a PAT rather than a JWT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PATPrefix)
+}
+
+// NewPersonalAccessToken returns a new token and the hash to store in its place.
+func NewPersonalAccessToken() (string, string, error) {
+	raw, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	raw = PATPrefix + raw
+	return raw, HashToken(raw), nil
+}
+
+// HasScope reports whether the scopes grant access to resource ("interviews")
+// at level ("read" or "write"). A write scope also grants read.
+func HasScope(scopes []string, resource, level string) bool {
+	for _, s := range scopes {
+		if s == resource+":"+level || (level == "read" && s == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthenticatePAT looks up a personal access token and loads its owner. Expired
+// and revoked tokens are rejected with ErrExpiredToken and ErrRevokedToken.
+func AuthenticatePAT(ctx context.Context, raw string) (*Principal, error) {
+	var pat models.PersonalAccessToken
+	err := database.GetCollection("personal_access_tokens").FindOne(ctx, bson.M{"token_hash": HashToken(raw)}).Decode(&pat)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pat.RevokedAt != nil {
+		return nil, ErrRevokedToken
+	}
+	now := time.Now().UTC()
+	if !now.Before(pat.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": pat.UserID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Banned {
+		return nil, ErrUserBanned
+	}
+
+	// Like sessions, last use is recorded at most once per touch interval
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= sessionTouchInterval {
+		_, err := database.GetCollection("personal_access_tokens").UpdateOne(ctx,
+			bson.M{"_id": pat.ID},
+			bson.M{"$set": bson.M{"last_used_at": now}},
+		)
+		if err != nil {
+			log.Printf("Error updating last use of personal access token %s: %v", pat.ID.Hex(), err)
+		}
+	}
+
+	return &Principal{
+		User:       user,
+		TokenID:    pat.ID.Hex(),
+		ExpiresAt:  pat.ExpiresAt,
+		ActiveRole: ResolveActiveRole(&user, ""),
+		OrgID:      ResolveActiveOrg(&user, pat.OrgID),
+		Scopes:     pat.Scopes,
+	}, nil
+}
+
+// RevokeAllPATsForUser revokes every personal access token the user has.
+func RevokeAllPATsForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := database.GetCollection("personal_access_tokens").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	return err
+}
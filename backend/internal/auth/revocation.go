@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Principal is the result of successfully authenticating an access token.
+type Principal struct {
+	User      models.User
+	TokenID   string
+	SessionID primitive.ObjectID
+	ExpiresAt time.Time
+	Claims    jwt.MapClaims
+	// ActiveRole is the role the caller is acting in. For session tokens it
+	// comes from the "active_role" claim; personal access tokens act in the
+	// user's signup role. It is empty if the user holds neither interviewer
+	// nor interviewee.
+	ActiveRole string
+	// OrgID is the organization (tenant) the caller is acting in, or nil for
+	// the public space. Like ActiveRole it comes from a claim for session
+	// tokens and is checked against the user's current memberships.
+	OrgID *primitive.ObjectID
+	// Scopes is only set for personal access tokens. Session tokens carry the
+	// user's full access and have a nil Scopes.
+	Scopes []string
+}
+
+// Authenticate parses an access token and checks it against the revocation
+// store: the token's jti must not be revoked, its "ver" claim must match the
+// user's current token version, its session must still be active and the user
+// must not be banned. Both AuthMiddleware and WebsocketHandler go
+// through here so they cannot disagree about what a valid token is.
+func Authenticate(ctx context.Context, tokenString string) (*Principal, error) {
+	claims, err := ParseAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	tokenID, _ := claims["jti"].(string)
+	if tokenID == "" {
+		return nil, ErrInvalidToken
+	}
+	sessionID, err := ClaimObjectID(claims, "sid")
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := IsTokenRevoked(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Banned {
+		return nil, ErrUserBanned
+	}
+
+	version, _ := claims["ver"].(float64) // JSON numbers decode as float64
+	if int(version) != user.TokenVersion {
+		return nil, ErrRevokedToken
+	}
+
+	session, err := ActiveSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := touchSession(ctx, session); err != nil {
+		log.Printf("Error updating last seen time of session %s: %v", sessionID.Hex(), err)
+	}
+
+	// The claim is checked against the user's current roles, so a role taken
+	// away by an admin stops working before the token expires
+	activeRole, _ := claims["active_role"].(string)
+	var requestedOrg *primitive.ObjectID
+	if orgID, err := ClaimObjectID(claims, "org_id"); err == nil {
+		requestedOrg = &orgID
+	}
+
+	exp, _ := claims["exp"].(float64)
+	return &Principal{
+		User:       user,
+		TokenID:    tokenID,
+		SessionID:  sessionID,
+		ExpiresAt:  time.Unix(int64(exp), 0).UTC(),
+		Claims:     claims,
+		ActiveRole: ResolveActiveRole(&user, activeRole),
+		OrgID:      ResolveActiveOrg(&user, requestedOrg),
+	}, nil
+}
+
+// RevokeToken adds an access token's jti to the revocation store. The entry is
+// kept until the token would have expired anyway.
+func RevokeToken(ctx context.Context, tokenID string, userID primitive.ObjectID, expiresAt time.Time) error {
+	_, err := database.GetCollection("revoked_tokens").UpdateOne(ctx,
+		bson.M{"_id": tokenID},
+		bson.M{"$setOnInsert": models.RevokedToken{
+			ID:        tokenID,
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+			RevokedAt: time.Now().UTC(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// IsTokenRevoked reports whether the jti is in the revocation store.
+func IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := database.GetCollection("revoked_tokens").CountDocuments(ctx, bson.M{"_id": tokenID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeAllForUser invalidates every access token issued to the user so far by
+// bumping their token version.
+func RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$inc": bson.M{"token_version": 1},
+			"$set": bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	return err
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// last_seen_at is written at most this often per session, so authenticated
+	// requests don't each cost a database write.
+	sessionTouchInterval = time.Minute
+	// User agents are stored for display only; anything longer is truncated.
+	maxUserAgentLength = 512
+)
+
+// StartSession records a login. The session ID doubles as the family ID of
+// the refresh tokens issued for it, so refreshing keeps the same session.
+// It is idempotent and never revives a session that was already revoked.
+func StartSession(ctx context.Context, sessionID, userID primitive.ObjectID, userAgent, ip string) error {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now().UTC()
+	_, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID},
+		bson.M{"$setOnInsert": models.Session{
+			ID:         sessionID,
+			UserID:     userID,
+			UserAgent:  userAgent,
+			IP:         ip,
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(config.AppConfig.RefreshTokenTTL),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ActiveSession returns the session if it belongs to the user and has been
+// neither revoked nor expired; otherwise ErrRevokedToken.
+func ActiveSession(ctx context.Context, sessionID, userID primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	err := database.GetCollection("sessions").FindOne(ctx, bson.M{
+		"_id":        sessionID,
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": time.Now().UTC()},
+	}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRevokedToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RenewSession marks the session as just used, extends it to expiresAt, the
+// expiry of its newest refresh token, and returns it. A revoked session is
+// left alone and reported as ErrRevokedToken.
+func RenewSession(ctx context.Context, sessionID primitive.ObjectID, expiresAt time.Time) (*models.Session, error) {
+	var session models.Session
+	err := database.GetCollection("sessions").FindOneAndUpdate(ctx,
+		bson.M{"_id": sessionID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"last_seen_at": time.Now().UTC(), "expires_at": expiresAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRevokedToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SetSessionActiveRole records the role the session acts in. Access tokens
+// issued for the session from then on carry it in their "active_role" claim.
+func SetSessionActiveRole(ctx context.Context, userID, sessionID primitive.ObjectID, role string) error {
+	result, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"active_role": role}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRevokedToken
+	}
+	return nil
+}
+
+// SetSessionActiveOrg records the organization the session acts in, or the
+// public space if orgID is nil.
+func SetSessionActiveOrg(ctx context.Context, userID, sessionID primitive.ObjectID, orgID *primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{"active_org_id": ""}}
+	if orgID != nil {
+		update = bson.M{"$set": bson.M{"active_org_id": *orgID}}
+	}
+	result, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "user_id": userID, "revoked_at": nil},
+		update,
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRevokedToken
+	}
+	return nil
+}
+
+// ResolveActiveOrg returns the organization a user acts in when they ask for
+// requested: requested itself if they are still a member, otherwise nil, the
+// public space. A removed member therefore loses access to the organization
+// with their next request.
+func ResolveActiveOrg(user *models.User, requested *primitive.ObjectID) *primitive.ObjectID {
+	if requested == nil {
+		return nil
+	}
+	for _, membership := range user.Memberships {
+		if membership.OrgID == *requested {
+			orgID := membership.OrgID
+			return &orgID
+		}
+	}
+	return nil
+}
+
+// ResolveActiveRole returns the role a user acts in when they ask for
+// requested. Only interviewer and interviewee can be active, and only if the
+// user holds them; otherwise the user's signup role is used, or failing that
+// their first other such role. Admin access is granted by holding the role
+// and is never an active role.
+func ResolveActiveRole(user *models.User, requested string) string {
+	holds := func(role string) bool {
+		if role != "interviewer" && role != "interviewee" {
+			return false
+		}
+		for _, r := range user.AvailableRoles {
+			if r == role {
+				return true
+			}
+		}
+		return false
+	}
+	if holds(requested) {
+		return requested
+	}
+	if holds(user.Role) {
+		return user.Role
+	}
+	for _, role := range user.AvailableRoles {
+		if holds(role) {
+			return role
+		}
+	}
+	return ""
+}
+
+// touchSession updates last_seen_at if it is older than sessionTouchInterval.
+// The filter makes concurrent requests race harmlessly to a single write.
+func touchSession(ctx context.Context, session *models.Session) error {
+	now := time.Now().UTC()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+	_, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": session.ID, "last_seen_at": bson.M{"$lt": now.Add(-sessionTouchInterval)}},
+		bson.M{"$set": bson.M{"last_seen_at": now}},
+	)
+	return err
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+func ListSessions(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	cursor, err := database.GetCollection("sessions").Find(ctx,
+		bson.M{
+			"user_id":    userID,
+			"revoked_at": nil,
+			"expires_at": bson.M{"$gt": time.Now().UTC()},
+		},
+		options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions. It reports false if the
+// session does not exist, belongs to someone else or was already revoked.
+// Callers are responsible for revoking the session's refresh tokens.
+func RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (bool, error) {
+	result, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RevokeAllSessions revokes every session the user has.
+func RevokeAllSessions(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := database.GetCollection("sessions").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	return err
+}
@@ -0,0 +1,102 @@
+// Package auth issues and verifies the tokens used by the API: short-lived
+// JWT access tokens, opaque server-side refresh tokens and signed single-use
+// action tokens for flows such as email verification. JWTs are signed with
+// asymmetric keys from a rotating key ring (see keyring.go).
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TokenTypeAccess is the "typ" claim carried by access tokens. Tokens without it
+// (e.g. the old 7-day login tokens) are rejected.
+const TokenTypeAccess = "access"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
+	ErrUserNotFound = errors.New("user associated with token not found")
+	ErrUserBanned   = errors.New("user is banned")
+)
+
+// NewAccessToken signs a short-lived access token for the user's session,
+// acting in activeRole within the organization orgID (nil for the public space).
+func NewAccessToken(user *models.User, sessionID primitive.ObjectID, activeRole string, orgID *primitive.ObjectID) (string, time.Time, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	orgClaim := ""
+	if orgID != nil {
+		orgClaim = orgID.Hex()
+	}
+	expiresAt := time.Now().Add(config.AppConfig.AccessTokenTTL)
+	tokenString, err := signClaims(jwt.MapClaims{
+		"typ":         TokenTypeAccess,
+		"jti":         tokenID,
+		"sid":         sessionID.Hex(),
+		"ver":         user.TokenVersion,
+		"user_id":     user.ID.Hex(),
+		"email":       user.Email,
+		"roles":       user.AvailableRoles,
+		"active_role": activeRole,
+		"org_id":      orgClaim,
+		"iat":         time.Now().Unix(),
+		"exp":         expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// ParseAccessToken validates the signature, expiry and type of an access token
+// and returns its claims.
+func ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if err := parseClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if typ, _ := claims["typ"].(string); typ != TokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// newTokenID returns a random identifier for the "jti" claim.
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewOpaqueToken returns a random URL-safe token together with the hash that
+// should be persisted in its place.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	return raw, HashToken(raw), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 of an opaque token.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// The RFC 4226 / RFC 6238 test secret "12345678901234567890"
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp(rfcKey, int64(counter)); got != code {
+			t.Errorf("hotp(counter %d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcKey)
+
+	// RFC 6238 appendix B (SHA1), truncated to our 6 digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		step, ok := ValidateTOTP(secret, v.code, time.Unix(v.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%s at %d) rejected a valid code", v.code, v.unix)
+			continue
+		}
+		if want := v.unix / 30; step != want {
+			t.Errorf("ValidateTOTP(%s at %d) matched step %d, want %d", v.code, v.unix, step, want)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     int64
+		ok     bool
+	}{
+		{"previous step", secret, "287082", 59 + 30, true},
+		{"next step", secret, "287082", 59 - 30, true},
+		{"two steps late", secret, "287082", 59 + 60, false},
+		{"surrounding spaces", secret, " 287082 ", 59, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", 59, true},
+		{"wrong code", secret, "287083", 59, false},
+		{"too short", secret, "28708", 59, false},
+		{"8 digits", secret, "94287082", 59, false},
+		{"invalid secret", "not base32!", "287082", 59, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0)); ok != tt.ok {
+				t.Errorf("ValidateTOTP() ok = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
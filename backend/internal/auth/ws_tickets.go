@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WSTicketTTL is how long a WebSocket ticket can be redeemed. Clients ask for
+// a ticket right before connecting, so this only needs to cover one round trip.
+const WSTicketTTL = 30 * time.Second
+
+// IssueWSTicket stores a one-time ticket for opening an interview-room socket
+// and returns the ticket to hand to the client. The caller fills in who the
+// ticket is for; the hash and timestamps are set here.
+func IssueWSTicket(ctx context.Context, ticket models.WSTicket) (string, time.Time, error) {
+	raw, hash, err := NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now().UTC()
+	ticket.ID = primitive.NewObjectID()
+	ticket.TicketHash = hash
+	ticket.CreatedAt = now
+	ticket.ExpiresAt = now.Add(WSTicketTTL)
+	if _, err := database.GetCollection("ws_tickets").InsertOne(ctx, ticket); err != nil {
+		return "", time.Time{}, err
+	}
+	return raw, ticket.ExpiresAt, nil
+}
+
+// RedeemWSTicket consumes a ticket for the interview. Deleting it in the same
+// operation makes it single-use. Unknown, expired and already used tickets,
+// and tickets for another interview, are all ErrInvalidToken.
+func RedeemWSTicket(ctx context.Context, raw string, interviewID primitive.ObjectID) (*models.WSTicket, error) {
+	var ticket models.WSTicket
+	err := database.GetCollection("ws_tickets").FindOneAndDelete(ctx, bson.M{
+		"ticket_hash":  HashToken(raw),
+		"interview_id": interviewID,
+		"expires_at":   bson.M{"$gt": time.Now().UTC()},
+	}).Decode(&ticket)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
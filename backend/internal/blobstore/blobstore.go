@@ -0,0 +1,120 @@
+// Package blobstore keeps uploaded files (such as profile pictures) in a
+// pluggable BlobStore: a directory on local disk or an S3-compatible bucket.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"mock-orbit/backend/internal/config"
+)
+
+// ErrNotFound is returned by Get when no blob is stored under the key.
+var ErrNotFound = errors.New("blobstore: blob not found")
+
+// errInvalidKey rejects keys that could escape the store's namespace.
+var errInvalidKey = errors.New("blobstore: invalid key")
+
+// Blob is a stored file being read. The caller must close Body.
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore stores files under slash-separated keys such as
+// "avatars/<user>/<version>-256.jpg". Implementations must be safe for
+// concurrent use. Put replaces an existing blob; deleting a missing key is not
+// an error.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	current BlobStore = &LocalStore{Dir: "./data/blobs"}
+	mu      sync.RWMutex
+)
+
+// Setup selects the BlobStore implementation from configuration.
+func Setup() {
+	cfg := config.AppConfig
+	switch cfg.BlobStore {
+	case "s3":
+		Set(&S3Store{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3ForcePathStyle,
+		})
+		log.Printf("Blob store: using bucket %s at %s", cfg.S3Bucket, cfg.S3Endpoint)
+	default:
+		Set(&LocalStore{Dir: cfg.BlobLocalDir})
+		log.Printf("Blob store: writing files to %s", cfg.BlobLocalDir)
+	}
+}
+
+// Set replaces the active BlobStore, e.g. with a temporary directory in tests.
+func Set(s BlobStore) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+func store() BlobStore {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Put stores data under key in the active BlobStore.
+func Put(ctx context.Context, key, contentType string, data []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return store().Put(ctx, key, contentType, data)
+}
+
+// Get opens the blob stored under key in the active BlobStore.
+func Get(ctx context.Context, key string) (*Blob, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	return store().Get(ctx, key)
+}
+
+// Delete removes the blob stored under key from the active BlobStore.
+func Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return store().Delete(ctx, key)
+}
+
+// validateKey accepts relative, slash-separated keys made of letters, digits,
+// dots, dashes and underscores, without empty, "." or ".." segments.
+func validateKey(key string) error {
+	if key == "" || len(key) > 512 {
+		return errInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errInvalidKey
+		}
+		for _, r := range segment {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			default:
+				return errInvalidKey
+			}
+		}
+	}
+	return nil
+}
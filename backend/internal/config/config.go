@@ -1,8 +1,12 @@
 package config
 
 import (
+	"encoding/base64"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,9 +14,115 @@ import (
 type Config struct {
 	MongoURI    string
 	DatabaseName string
-	JWTSecret   string
 	ServerPort  string
 	FrontendURL string // Added for CORS configuration
+
+	// JWT signing. Keys are generated and stored in the database; a new key
+	// takes over every JWTKeyRotationInterval and retired keys keep verifying
+	// tokens for JWTKeyVerificationGrace.
+	JWTSigningAlg           string // "RS256" or "EdDSA"
+	JWTKeyRotationInterval  time.Duration
+	JWTKeyVerificationGrace time.Duration
+	// 32-byte AES key the private signing keys are encrypted with at rest
+	JWTKeyEncryptionKey []byte
+
+	// Token lifetimes. Access tokens are short-lived JWTs; refresh tokens are
+	// opaque, stored server-side and rotated on every use.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// PublicURL is the externally reachable base URL of this API, used to build
+	// links in outgoing email.
+	PublicURL string
+
+	// Mail delivery. MailDriver is "smtp" or "log" (development/test sink).
+	MailDriver   string
+	MailFrom     string
+	MailLogDir   string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	EmailVerificationTTL            time.Duration
+	EmailVerificationResendInterval time.Duration
+	PasswordResetTTL                time.Duration
+	// Minimum time between reset emails to one account; a client IP gets a
+	// few requests before it backs off from the same interval
+	PasswordResetResendInterval time.Duration
+	// How long the old address can undo an email change. The link to confirm
+	// the new address uses EmailVerificationTTL.
+	EmailChangeRevertTTL time.Duration
+
+	// How long guest links to an interview stay valid.
+	GuestLinkTTL time.Duration
+
+	// How long a deleted account can still be restored before it is purged.
+	AccountDeletionGracePeriod time.Duration
+
+	// Storage for uploaded files such as profile pictures. BlobStore is "local"
+	// (files under BlobLocalDir) or "s3" (any S3-compatible service; path-style
+	// addressing is needed for most self-hosted ones such as MinIO).
+	BlobStore         string
+	BlobLocalDir      string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3ForcePathStyle  bool
+
+	// Largest profile picture upload accepted, in bytes.
+	AvatarMaxBytes int
+
+	// Which interviews get a user's new name after a rename, and how often
+	// pending renames are retried. Policies: "future" (scheduled from now
+	// on), "unfinished" (not completed or cancelled) or "all".
+	NamePropagationPolicy string
+	NameSyncInterval      time.Duration
+
+	// Brute-force protection for logins. LoginThrottleStore is "mongo" (shared
+	// between replicas) or "memory". After a few free failures each attempt
+	// backs off exponentially from LoginBackoffBase up to LoginBackoffMax;
+	// reaching the failure limit locks the account or IP for LoginLockoutDuration.
+	LoginThrottleStore      string
+	LoginMaxAccountFailures int
+	LoginMaxIPFailures      int
+	LoginBackoffBase        time.Duration
+	LoginBackoffMax         time.Duration
+	LoginLockoutDuration    time.Duration
+
+	// Verified accounts with these emails are granted the admin role at startup.
+	AdminEmails []string
+
+	// Rules under which a requested additional role is granted without admin
+	// review. All rules for a role must pass.
+	RoleAutoApprovalRules []RoleApprovalRule
+
+	// OpenID Connect providers available for social login, keyed by name.
+	OIDCProviders map[string]OIDCProviderConfig
+}
+
+// OIDCProviderConfig describes an OpenID Connect identity provider. Everything
+// is discovered from the issuer, so pointing Issuer at a local mock IdP is
+// enough for testing.
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// RoleApprovalRule auto-approves requests for Role once the requesting user's
+// Metric reaches Threshold. Supported metrics are completed_as_interviewee,
+// completed_as_interviewer and account_age_days.
+type RoleApprovalRule struct {
+	Role      string
+	Metric    string
+	Threshold int
 }
 
 var AppConfig *Config
@@ -27,14 +137,157 @@ func LoadConfig() {
 	AppConfig = &Config{
 		MongoURI:    getEnv("MONGODB_URI", ""),
 		DatabaseName: getEnv("DATABASE_NAME", "mock_orbit"),
-		JWTSecret:   getEnv("JWT_SECRET", "default_secret"),
 		ServerPort:  getEnv("SERVER_PORT", "8080"),
 		FrontendURL: getEnv("FRONTEND_URL", ""), // Frontend URL strictly from environment
+
+		JWTSigningAlg:           getEnv("JWT_SIGNING_ALG", "RS256"),
+		JWTKeyRotationInterval:  getEnvDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+		JWTKeyVerificationGrace: getEnvDuration("JWT_KEY_VERIFICATION_GRACE", 7*24*time.Hour),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		MailDriver:   getEnv("MAIL_DRIVER", ""),
+		MailFrom:     getEnv("MAIL_FROM", "Mock Orbit <no-reply@mockorbit.local>"),
+		MailLogDir:   getEnv("MAIL_LOG_DIR", ""),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		EmailVerificationTTL:            getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		EmailVerificationResendInterval: getEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		PasswordResetTTL:                getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetResendInterval:     getEnvDuration("PASSWORD_RESET_RESEND_INTERVAL", time.Minute),
+		EmailChangeRevertTTL:            getEnvDuration("EMAIL_CHANGE_REVERT_TTL", 7*24*time.Hour),
+
+		GuestLinkTTL:               getEnvDuration("GUEST_LINK_TTL", 7*24*time.Hour),
+		AccountDeletionGracePeriod: getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour),
+
+		BlobStore:         getEnv("BLOB_STORE", "local"),
+		BlobLocalDir:      getEnv("BLOB_LOCAL_DIR", "./data/blobs"),
+		S3Endpoint:        strings.TrimRight(getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"), "/"),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3ForcePathStyle:  getEnv("S3_FORCE_PATH_STYLE", "false") == "true",
+
+		AvatarMaxBytes: getEnvInt("AVATAR_MAX_BYTES", 5<<20),
+
+		NamePropagationPolicy: getEnv("NAME_PROPAGATION_POLICY", "future"),
+		NameSyncInterval:      getEnvDuration("NAME_SYNC_INTERVAL", 30*time.Second),
+
+		LoginThrottleStore:      getEnv("LOGIN_THROTTLE_STORE", "mongo"),
+		LoginMaxAccountFailures: getEnvInt("LOGIN_MAX_ACCOUNT_FAILURES", 10),
+		LoginMaxIPFailures:      getEnvInt("LOGIN_MAX_IP_FAILURES", 50),
+		LoginBackoffBase:        getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:         getEnvDuration("LOGIN_BACKOFF_MAX", time.Minute),
+		LoginLockoutDuration:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+	}
+	AppConfig.PublicURL = strings.TrimRight(getEnv("PUBLIC_URL", "http://localhost:"+AppConfig.ServerPort), "/")
+
+	AppConfig.OIDCProviders = loadOIDCProviders(AppConfig.PublicURL)
+	AppConfig.RoleAutoApprovalRules = loadRoleApprovalRules()
+	for _, email := range strings.Split(getEnv("ADMIN_EMAILS", ""), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			AppConfig.AdminEmails = append(AppConfig.AdminEmails, email)
+		}
+	}
+
+	if AppConfig.JWTSigningAlg != "RS256" && AppConfig.JWTSigningAlg != "EdDSA" {
+		log.Printf("Warning: unsupported JWT_SIGNING_ALG %q, using RS256", AppConfig.JWTSigningAlg)
+		AppConfig.JWTSigningAlg = "RS256"
+	}
+	if encoded := getEnv("JWT_KEY_ENCRYPTION_KEY", ""); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			log.Fatal("JWT_KEY_ENCRYPTION_KEY must be 32 bytes, base64 encoded (e.g. openssl rand -base64 32)")
+		}
+		AppConfig.JWTKeyEncryptionKey = key
+	} else {
+		log.Println("Warning: JWT_KEY_ENCRYPTION_KEY is not set. Private signing keys are stored unencrypted in MongoDB.")
+	}
+	if AppConfig.JWTKeyVerificationGrace < AppConfig.AccessTokenTTL {
+		log.Println("Warning: JWT_KEY_VERIFICATION_GRACE is shorter than ACCESS_TOKEN_TTL; tokens may fail verification after a key rotation.")
+	}
+	switch AppConfig.NamePropagationPolicy {
+	case "future", "unfinished", "all":
+	default:
+		log.Printf("Warning: unsupported NAME_PROPAGATION_POLICY %q, using future", AppConfig.NamePropagationPolicy)
+		AppConfig.NamePropagationPolicy = "future"
+	}
+	if AppConfig.BlobStore == "s3" && AppConfig.S3Bucket == "" {
+		log.Println("Warning: BLOB_STORE is s3 but S3_BUCKET is not set. Uploads will fail.")
+	}
+	switch AppConfig.MailDriver {
+	case "smtp", "log":
+	case "":
+		log.Println("Warning: MAIL_DRIVER is not set, using log. Every email, including verification, password reset and guest links, is printed to the log; set MAIL_DRIVER=smtp in production.")
+		AppConfig.MailDriver = "log"
+	default:
+		log.Printf("Warning: unsupported MAIL_DRIVER %q, using log. Every email, including its links, is printed to the log.", AppConfig.MailDriver)
+		AppConfig.MailDriver = "log"
+	}
+	if AppConfig.MailDriver == "smtp" && AppConfig.SMTPHost == "" {
+		log.Println("Warning: MAIL_DRIVER is smtp but SMTP_HOST is not set. Outgoing mail will fail.")
+	}
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS (a comma-separated list of names) and
+// OIDC_<NAME>_* variables for each provider.
+func loadOIDCProviders(publicURL string) map[string]OIDCProviderConfig {
+	providers := make(map[string]OIDCProviderConfig)
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       strings.TrimRight(getEnv(prefix+"ISSUER", ""), "/"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", publicURL+"/api/v1/auth/oidc/"+name+"/callback"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("Warning: OIDC provider %q is missing %sISSUER or %sCLIENT_ID and will be ignored", name, prefix, prefix)
+			continue
+		}
+		providers[name] = provider
 	}
+	return providers
+}
 
-	if AppConfig.JWTSecret == "default_secret" {
-		log.Println("Warning: JWT_SECRET is set to the default value. Please set a strong secret in your environment.")
+// loadRoleApprovalRules parses ROLE_AUTO_APPROVAL_RULES, a comma-separated list
+// of role:metric>=threshold entries such as "interviewer:completed_as_interviewee>=3".
+func loadRoleApprovalRules() []RoleApprovalRule {
+	var rules []RoleApprovalRule
+	for _, entry := range strings.Split(getEnv("ROLE_AUTO_APPROVAL_RULES", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, condition, ok := strings.Cut(entry, ":")
+		metric, threshold, ok2 := strings.Cut(condition, ">=")
+		n, err := strconv.Atoi(strings.TrimSpace(threshold))
+		metric = strings.TrimSpace(metric)
+		if !ok || !ok2 || err != nil || n < 0 {
+			log.Printf("Warning: ignoring malformed role approval rule %q (expected role:metric>=N)", entry)
+			continue
+		}
+		switch metric {
+		case "completed_as_interviewee", "completed_as_interviewer", "account_age_days":
+		default:
+			log.Printf("Warning: ignoring role approval rule %q with unknown metric %q", entry, metric)
+			continue
+		}
+		rules = append(rules, RoleApprovalRule{Role: strings.TrimSpace(role), Metric: metric, Threshold: n})
 	}
+	return rules
 }
 
 func getEnv(key, fallback string) string {
@@ -42,4 +295,32 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// getEnvInt parses an integer from the environment.
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid integer for %s (%q), using default %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvDuration parses a Go duration string (e.g. "15m", "720h") from the environment.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid duration for %s (%q), using default %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -7,6 +7,7 @@ import (
 
 	"mock-orbit/backend/internal/config"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -67,6 +68,48 @@ func SetupIndexes(ctx context.Context, db *mongo.Database) {
 		log.Println("User email index created successfully.")
 	}
 
+	// Peer directory: prefix search and keyset pagination by name. A text
+	// index only matches whole stemmed words, so it can't serve the prefix
+	// queries the directory needs; an index on the lowercased name words in
+	// search_keys can, with an anchored regex.
+	peerIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"search_keys": 1},
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+		},
+	}
+	_, err = userCollection.Indexes().CreateMany(ctx, peerIndexes)
+	if err != nil {
+		log.Printf("Error creating user search indexes: %v", err)
+	} else {
+		log.Println("User search indexes created successfully.")
+	}
+
+	// Lists the members of an organization and the peers in it
+	membershipIndex := mongo.IndexModel{
+		Keys: map[string]interface{}{"memberships.org_id": 1},
+	}
+	_, err = userCollection.Indexes().CreateOne(ctx, membershipIndex)
+	if err != nil {
+		log.Printf("Error creating user membership index: %v", err)
+	} else {
+		log.Println("User membership index created successfully.")
+	}
+
+	// Lets the purge worker find accounts whose deletion grace period is over
+	deletionIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"deletion_scheduled_for": 1},
+		Options: options.Index().SetSparse(true),
+	}
+	_, err = userCollection.Indexes().CreateOne(ctx, deletionIndex)
+	if err != nil {
+		log.Printf("Error creating user deletion index: %v", err)
+	} else {
+		log.Println("User deletion index created successfully.")
+	}
+
 	// Add other indexes as needed for interviews, etc.
 	interviewCollection := db.Collection("interviews")
 	scheduledTimeIndex := mongo.IndexModel{
@@ -88,6 +131,251 @@ func SetupIndexes(ctx context.Context, db *mongo.Database) {
 	} else {
 		log.Println("Interview participant index created successfully.")
 	}
+
+	// Lets verifying an email find the guest history it can claim
+	guestEmailIndex := mongo.IndexModel{
+		Keys: map[string]interface{}{"guests.email": 1},
+	}
+	_, err = interviewCollection.Indexes().CreateOne(ctx, guestEmailIndex)
+	if err != nil {
+		log.Printf("Error creating interview guest email index: %v", err)
+	} else {
+		log.Println("Interview guest email index created successfully.")
+	}
+
+	claimedGuestIndex := mongo.IndexModel{
+		Keys: map[string]interface{}{"guests.claimed_by": 1},
+	}
+	_, err = interviewCollection.Indexes().CreateOne(ctx, claimedGuestIndex)
+	if err != nil {
+		log.Printf("Error creating interview claimed guest index: %v", err)
+	} else {
+		log.Println("Interview claimed guest index created successfully.")
+	}
+
+	refreshTokenCollection := db.Collection("refresh_tokens")
+	refreshTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"token_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"family_id": 1},
+		},
+		{
+			// Let MongoDB purge expired refresh tokens
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = refreshTokenCollection.Indexes().CreateMany(ctx, refreshTokenIndexes)
+	if err != nil {
+		log.Printf("Error creating refresh token indexes: %v", err)
+	} else {
+		log.Println("Refresh token indexes created successfully.")
+	}
+
+	revokedTokenCollection := db.Collection("revoked_tokens")
+	revokedTokenIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = revokedTokenCollection.Indexes().CreateOne(ctx, revokedTokenIndex)
+	if err != nil {
+		log.Printf("Error creating revoked token index: %v", err)
+	} else {
+		log.Println("Revoked token index created successfully.")
+	}
+
+	usedActionTokenCollection := db.Collection("used_action_tokens")
+	usedActionTokenIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = usedActionTokenCollection.Indexes().CreateOne(ctx, usedActionTokenIndex)
+	if err != nil {
+		log.Printf("Error creating used action token index: %v", err)
+	} else {
+		log.Println("Used action token index created successfully.")
+	}
+
+	identityIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+	}
+	_, err = userCollection.Indexes().CreateOne(ctx, identityIndex)
+	if err != nil {
+		log.Printf("Error creating user identity index: %v", err)
+	} else {
+		log.Println("User identity index created successfully.")
+	}
+
+	oidcStateCollection := db.Collection("oidc_states")
+	oidcStateIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = oidcStateCollection.Indexes().CreateOne(ctx, oidcStateIndex)
+	if err != nil {
+		log.Printf("Error creating OIDC state index: %v", err)
+	} else {
+		log.Println("OIDC state index created successfully.")
+	}
+
+	signingKeyCollection := db.Collection("signing_keys")
+	signingKeyIndex := mongo.IndexModel{
+		// Keys are dropped once nothing signed with them can still be valid
+		Keys:    map[string]interface{}{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = signingKeyCollection.Indexes().CreateOne(ctx, signingKeyIndex)
+	if err != nil {
+		log.Printf("Error creating signing key index: %v", err)
+	} else {
+		log.Println("Signing key index created successfully.")
+	}
+
+	sessionCollection := db.Collection("sessions")
+	sessionIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"user_id": 1},
+		},
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = sessionCollection.Indexes().CreateMany(ctx, sessionIndexes)
+	if err != nil {
+		log.Printf("Error creating session indexes: %v", err)
+	} else {
+		log.Println("Session indexes created successfully.")
+	}
+
+	patCollection := db.Collection("personal_access_tokens")
+	patIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"token_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"user_id": 1},
+		},
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = patCollection.Indexes().CreateMany(ctx, patIndexes)
+	if err != nil {
+		log.Printf("Error creating personal access token indexes: %v", err)
+	} else {
+		log.Println("Personal access token indexes created successfully.")
+	}
+
+	roleRequestCollection := db.Collection("role_requests")
+	roleRequestIndexes := []mongo.IndexModel{
+		{
+			// At most one pending request per user and role
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "role", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"status": "pending"}),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	}
+	_, err = roleRequestCollection.Indexes().CreateMany(ctx, roleRequestIndexes)
+	if err != nil {
+		log.Printf("Error creating role request indexes: %v", err)
+	} else {
+		log.Println("Role request indexes created successfully.")
+	}
+
+	// One block per pair and direction; lookups go both ways
+	userBlockCollection := db.Collection("user_blocks")
+	userBlockIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "blocker_id", Value: 1}, {Key: "blocked_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"blocked_id": 1},
+		},
+	}
+	_, err = userBlockCollection.Indexes().CreateMany(ctx, userBlockIndexes)
+	if err != nil {
+		log.Printf("Error creating user block indexes: %v", err)
+	} else {
+		log.Println("User block indexes created successfully.")
+	}
+
+	// Moderation queue by status, and reports about one user
+	userReportCollection := db.Collection("user_reports")
+	userReportIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+		},
+		{
+			Keys: map[string]interface{}{"reported_id": 1},
+		},
+		{
+			Keys: map[string]interface{}{"reporter_id": 1},
+		},
+	}
+	_, err = userReportCollection.Indexes().CreateMany(ctx, userReportIndexes)
+	if err != nil {
+		log.Printf("Error creating user report indexes: %v", err)
+	} else {
+		log.Println("User report indexes created successfully.")
+	}
+
+	auditLogCollection := db.Collection("audit_logs")
+	auditLogIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"createdAt": -1},
+		},
+		{
+			Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	}
+	_, err = auditLogCollection.Indexes().CreateMany(ctx, auditLogIndexes)
+	if err != nil {
+		log.Printf("Error creating audit log indexes: %v", err)
+	} else {
+		log.Println("Audit log indexes created successfully.")
+	}
+
+	failedAttemptCollection := db.Collection("failed_attempts")
+	failedAttemptIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = failedAttemptCollection.Indexes().CreateOne(ctx, failedAttemptIndex)
+	if err != nil {
+		log.Printf("Error creating failed attempt index: %v", err)
+	} else {
+		log.Println("Failed attempt index created successfully.")
+	}
+
+	wsTicketCollection := db.Collection("ws_tickets")
+	wsTicketIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"ticket_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err = wsTicketCollection.Indexes().CreateMany(ctx, wsTicketIndexes)
+	if err != nil {
+		log.Printf("Error creating WebSocket ticket indexes: %v", err)
+	} else {
+		log.Println("WebSocket ticket indexes created successfully.")
+	}
 }
 
 // Helper function to get a collection
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// deletedUserName replaces a deleted user's name wherever other people's
+	// records still mention them.
+	deletedUserName = "Deleted user"
+	// How often the purge worker looks for accounts past their grace period,
+	// and how many it handles per pass.
+	accountPurgeInterval  = time.Hour
+	accountPurgeBatchSize = 100
+)
+
+// errNameSyncRunning defers a purge while a name sync of the user may still
+// be writing their old name into interviews.
+var errNameSyncRunning = errors.New("name sync of the user is running; purging on the next pass")
+
+// ExportAccountHandler returns everything stored about the current user as a
+// JSON download: profile, linked identities, interviews, sessions, personal
+// access tokens, role requests, blocks and reports the user made, and admin
+// actions taken on the account.
+// Credentials and secrets are never included.
+func ExportAccountHandler(c *gin.Context) {
+	ctx := context.Background()
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for export: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	interviews := []models.Interview{}
+	sessions := []models.Session{}
+	tokens := []models.PersonalAccessToken{}
+	roleRequests := []models.RoleRequest{}
+	auditLogs := []models.AuditLog{}
+	blocks := []models.UserBlock{}
+	reports := []models.UserReport{}
+	queries := []struct {
+		collection string
+		filter     bson.M
+		sortKey    string
+		out        interface{}
+	}{
+		{"interviews", bson.M{"$or": []bson.M{
+			{"interviewer_id": userID},
+			{"interviewee_id": userID},
+			{"guests.claimed_by": userID},
+		}}, "scheduled_time", &interviews},
+		{"sessions", bson.M{"user_id": userID}, "createdAt", &sessions},
+		{"personal_access_tokens", bson.M{"user_id": userID}, "createdAt", &tokens},
+		{"role_requests", bson.M{"user_id": userID}, "createdAt", &roleRequests},
+		{"audit_logs", bson.M{"target_id": userID.Hex()}, "createdAt", &auditLogs},
+		{"user_blocks", bson.M{"blocker_id": userID}, "createdAt", &blocks},
+		{"user_reports", bson.M{"reporter_id": userID}, "createdAt", &reports},
+	}
+	for _, q := range queries {
+		cursor, err := database.GetCollection(q.collection).Find(ctx, q.filter,
+			options.Find().SetSort(bson.D{{Key: q.sortKey, Value: -1}}),
+		)
+		if err == nil {
+			err = cursor.All(ctx, q.out)
+		}
+		if err != nil {
+			log.Printf("Error exporting %s for user %s: %v", q.collection, userID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+			return
+		}
+	}
+
+	sessionExport := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		sessionExport = append(sessionExport, gin.H{
+			"id":         s.ID,
+			"userAgent":  s.UserAgent,
+			"ip":         s.IP,
+			"createdAt":  s.CreatedAt,
+			"lastSeenAt": s.LastSeenAt,
+			"expiresAt":  s.ExpiresAt,
+			"revokedAt":  s.RevokedAt,
+		})
+	}
+	tokenExport := make([]models.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		tokenExport = append(tokenExport, newPersonalAccessTokenResponse(&tokens[i]))
+	}
+	interviewExport := make([]gin.H, 0, len(interviews))
+	for i := range interviews {
+		interviewExport = append(interviewExport, newInterviewExport(&interviews[i], userID))
+	}
+	auditExport := make([]gin.H, 0, len(auditLogs))
+	for _, entry := range auditLogs {
+		// Which admin acted is not the user's data; what was done is
+		auditExport = append(auditExport, gin.H{
+			"action":    entry.Action,
+			"details":   entry.Details,
+			"createdAt": entry.CreatedAt,
+		})
+	}
+
+	identities := user.Identities
+	if identities == nil {
+		identities = []models.ExternalIdentity{}
+	}
+	memberships := user.Memberships
+	if memberships == nil {
+		memberships = []models.OrgMembership{}
+	}
+	export := gin.H{
+		"generatedAt":          time.Now().UTC(),
+		"profile":              newUserResponse(&user),
+		"identities":           identities,
+		"organizations":        memberships,
+		"interviews":           interviewExport,
+		"sessions":             sessionExport,
+		"personalAccessTokens": tokenExport,
+		"roleRequests":         roleRequests,
+		"adminActions":         auditExport,
+		"blockedUsers":         blocks,
+		"reportsFiled":         reports,
+	}
+
+	log.Printf("User %s exported their account data", user.Email)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="mock-orbit-export-%s.json"`, userID.Hex()))
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// newInterviewExport is an interview as it appears in the user's export: what
+// it was and how it went, plus what the user themselves did and wrote. Other
+// participants' names, guests' emails and other people's messages are theirs,
+// not the user's, and are left out.
+func newInterviewExport(interview *models.Interview, userID primitive.ObjectID) gin.H {
+	role := participantRole(interview, userID)
+	var guest *models.InterviewGuest
+	for i := range interview.Guests {
+		if g := &interview.Guests[i]; g.ClaimedBy != nil && *g.ClaimedBy == userID {
+			guest = g
+			if role == "" {
+				role = "guest"
+			}
+		}
+	}
+
+	history := make([]gin.H, 0, len(interview.StatusHistory))
+	for _, change := range interview.StatusHistory {
+		entry := gin.H{"from": change.From, "to": change.To, "at": change.At, "byYou": change.ActorID == userID}
+		if change.ActorID == userID && change.Reason != "" {
+			entry["reason"] = change.Reason
+		}
+		history = append(history, entry)
+	}
+	proposals := make([]gin.H, 0, len(interview.RescheduleProposals))
+	for _, p := range interview.RescheduleProposals {
+		entry := gin.H{
+			"times":         p.Times,
+			"previousTime":  p.PreviousTime,
+			"status":        p.Status,
+			"createdAt":     p.CreatedAt,
+			"proposedByYou": p.ProposedBy == userID,
+		}
+		if p.ProposedBy == userID && p.Message != "" {
+			entry["message"] = p.Message
+		}
+		if p.RespondedAt != nil {
+			entry["respondedAt"] = p.RespondedAt
+		}
+		if p.RespondedBy != nil && *p.RespondedBy == userID && p.ResponseMessage != "" {
+			entry["responseMessage"] = p.ResponseMessage
+		}
+		if p.AcceptedTime != nil {
+			entry["acceptedTime"] = p.AcceptedTime
+		}
+		proposals = append(proposals, entry)
+	}
+
+	export := gin.H{
+		"id":                  interview.ID,
+		"role":                role,
+		"scheduledTime":       interview.ScheduledTime,
+		"topic":               interview.Topic,
+		"status":              interview.Status,
+		"createdAt":           interview.CreatedAt,
+		"updatedAt":           interview.UpdatedAt,
+		"statusHistory":       history,
+		"rescheduleProposals": proposals,
+	}
+	if interview.OrgID != nil {
+		export["orgId"] = interview.OrgID
+	}
+	if guest != nil {
+		// The invitation the user accepted before they had an account
+		export["guestInvitation"] = gin.H{
+			"name":      guest.Name,
+			"email":     guest.Email,
+			"invitedAt": guest.InvitedAt,
+			"joinedAt":  guest.JoinedAt,
+			"claimedAt": guest.ClaimedAt,
+		}
+	}
+	return export
+}
+
+// DeleteAccountHandler schedules the current user's account for deletion. The
+// user is logged out everywhere straight away; logging back in and calling
+// CancelAccountDeletionHandler within the grace period undoes the request.
+func DeleteAccountHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.DeleteAccountInput
+
+	if !bindOptionalJSON(c, &input) {
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for deletion: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.DeletionScheduledFor != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled", "deletion_scheduled_for": user.DeletionScheduledFor})
+		return
+	}
+	// Accounts created through social login may have no password to confirm
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+			log.Printf("Account deletion rejected for user %s: password incorrect", userID.Hex())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	scheduledFor := now.Add(config.AppConfig.AccountDeletionGracePeriod)
+	_, err := userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID, "deletion_scheduled_for": nil},
+		bson.M{"$set": bson.M{
+			"deletion_requested_at":  now,
+			"deletion_scheduled_for": scheduledFor,
+			"updatedAt":              now,
+		}},
+	)
+	if err != nil {
+		log.Printf("Error scheduling deletion of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	if err := revokeAllSessions(context.Background(), userID); err != nil {
+		log.Printf("Error revoking sessions of user %s after deletion request: %v", userID.Hex(), err)
+	}
+
+	err = mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "Your Mock Orbit account will be deleted",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to delete your Mock Orbit account. It will be deleted permanently on %s UTC.\n\nChanged your mind? Log in before then and cancel the deletion from your profile.\n",
+			user.Name, scheduledFor.Format("2006-01-02 15:04")),
+	})
+	if err != nil {
+		log.Printf("Error sending deletion notice to %s: %v", user.Email, err)
+	}
+
+	log.Printf("User %s scheduled their account for deletion on %s", user.Email, scheduledFor.Format(time.RFC3339))
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":                "Your account will be deleted at the end of the grace period. Log in again before then to cancel.",
+		"deletion_scheduled_for": scheduledFor,
+	})
+}
+
+// CancelAccountDeletionHandler restores an account whose deletion is still in
+// its grace period.
+func CancelAccountDeletionHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	result, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": userID, "deletion_scheduled_for": bson.M{"$gt": time.Now().UTC()}},
+		bson.M{
+			"$unset": bson.M{"deletion_requested_at": "", "deletion_scheduled_for": ""},
+			"$set":   bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		log.Printf("Error cancelling deletion of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion is scheduled"})
+		return
+	}
+
+	log.Printf("User %s cancelled their account deletion", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
+
+// StartAccountPurge periodically purges accounts whose deletion grace period
+// has passed. It stops when ctx is cancelled.
+func StartAccountPurge(ctx context.Context) {
+	ticker := time.NewTicker(accountPurgeInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			purgeDueAccounts(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func purgeDueAccounts(ctx context.Context) {
+	cursor, err := database.GetCollection("users").Find(ctx,
+		bson.M{"deletion_scheduled_for": bson.M{"$lte": time.Now().UTC()}},
+		options.Find().SetLimit(accountPurgeBatchSize),
+	)
+	if err != nil {
+		log.Printf("Error finding accounts due for deletion: %v", err)
+		return
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Error decoding accounts due for deletion: %v", err)
+		return
+	}
+	for i := range users {
+		if err := purgeAccount(ctx, &users[i]); err != nil {
+			// Every step is idempotent, so the next pass picks up where this left off
+			log.Printf("Error purging account %s: %v", users[i].ID.Hex(), err)
+			continue
+		}
+		log.Printf("Purged deleted account %s", users[i].ID.Hex())
+	}
+}
+
+// purgeAccount anonymizes the user in other people's interviews, removes their
+// credentials and personal records, and finally deletes the user document.
+// Interviews stay, so the other participant keeps their history.
+func purgeAccount(ctx context.Context, user *models.User) error {
+	interviews := database.GetCollection("interviews")
+	now := time.Now().UTC()
+
+	// A pending rename must not write the real name back afterwards. Removing
+	// the job stops a running one before its next write; one that may be
+	// writing right now gets until the next pass to finish.
+	var job struct {
+		NameSync *models.NameSyncJob `bson:"name_sync"`
+	}
+	err := database.GetCollection("users").FindOneAndUpdate(ctx,
+		bson.M{"_id": user.ID, "name_sync": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"name_sync": ""}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"name_sync": 1}),
+	).Decode(&job)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if job.NameSync != nil && job.NameSync.LeaseExpiresAt != nil && job.NameSync.LeaseExpiresAt.After(now) {
+		return errNameSyncRunning
+	}
+	if _, err := interviews.UpdateMany(ctx,
+		bson.M{"interviewer_id": user.ID},
+		bson.M{"$set": bson.M{"interviewer_name": deletedUserName, "updatedAt": now}},
+	); err != nil {
+		return err
+	}
+	if _, err := interviews.UpdateMany(ctx,
+		bson.M{"interviewee_id": user.ID},
+		bson.M{"$set": bson.M{"interviewee_name": deletedUserName, "updatedAt": now}},
+	); err != nil {
+		return err
+	}
+
+	// Guest entries the user claimed, or that were sent to their address
+	email := strings.ToLower(strings.TrimSpace(user.Email))
+	if _, err := interviews.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{{"guests.claimed_by": user.ID}, {"guests.email": email}}},
+		bson.M{"$set": bson.M{"guests.$[g].name": deletedUserName, "guests.$[g].email": "", "updatedAt": now}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"$or": []bson.M{{"g.claimed_by": user.ID}, {"g.email": email}}}},
+		}),
+	); err != nil {
+		return err
+	}
+
+	for _, collection := range []string{"sessions", "refresh_tokens", "personal_access_tokens", "revoked_tokens", "ws_tickets", "role_requests"} {
+		if _, err := database.GetCollection(collection).DeleteMany(ctx, bson.M{"user_id": user.ID}); err != nil {
+			return err
+		}
+	}
+
+	deleteAvatarBlobs(ctx, user.ID, user.Avatar)
+
+	if _, err := database.GetCollection("user_blocks").DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"blocker_id": user.ID},
+		{"blocked_id": user.ID},
+	}}); err != nil {
+		return err
+	}
+	// Reports stay in the moderation history, without the user's name
+	reports := database.GetCollection("user_reports")
+	if _, err := reports.UpdateMany(ctx, bson.M{"reporter_id": user.ID}, bson.M{"$set": bson.M{"reporter_name": deletedUserName}}); err != nil {
+		return err
+	}
+	if _, err := reports.UpdateMany(ctx, bson.M{"reported_id": user.ID}, bson.M{"$set": bson.M{"reported_name": deletedUserName}}); err != nil {
+		return err
+	}
+
+	// Removing the user last means a failed purge is retried in full. The
+	// deadline is checked again in case the schedule changed since loading.
+	_, err = database.GetCollection("users").DeleteOne(ctx, bson.M{
+		"_id":                    user.ID,
+		"deletion_scheduled_for": bson.M{"$lte": now},
+	})
+	return err
+}
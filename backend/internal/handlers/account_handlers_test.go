@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mock-orbit/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewInterviewExportLeavesOutOtherPeoplesData(t *testing.T) {
+	user, other := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now().UTC()
+	interview := &models.Interview{
+		ID:              primitive.NewObjectID(),
+		InterviewerID:   other,
+		IntervieweeID:   user,
+		InterviewerName: "Other Person",
+		IntervieweeName: "Export User",
+		ScheduledTime:   now,
+		Topic:           "System design",
+		Status:          "cancelled",
+		Guests: []models.InterviewGuest{
+			{ID: primitive.NewObjectID(), Name: "Third Party", Email: "third@example.com", InvitedBy: other},
+		},
+		StatusHistory: []models.StatusChange{
+			{From: "scheduled", To: "cancelled", ActorID: other, ActorRole: "interviewer", Reason: "their reason", At: now},
+		},
+		RescheduleProposals: []models.RescheduleProposal{
+			{ID: primitive.NewObjectID(), ProposedBy: other, Times: []time.Time{now}, Message: "their message", Status: "declined",
+				RespondedBy: &user, RespondedAt: &now, ResponseMessage: "my answer"},
+		},
+	}
+
+	raw, err := json.Marshal(newInterviewExport(interview, user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	export := string(raw)
+	for _, leaked := range []string{"Other Person", other.Hex(), "Third Party", "third@example.com", "their reason", "their message"} {
+		if strings.Contains(export, leaked) {
+			t.Errorf("export contains %q: %s", leaked, export)
+		}
+	}
+	for _, kept := range []string{`"role":"interviewee"`, "System design", "my answer"} {
+		if !strings.Contains(export, kept) {
+			t.Errorf("export is missing %q: %s", kept, export)
+		}
+	}
+}
+
+func TestNewInterviewExportIncludesClaimedGuestInvitation(t *testing.T) {
+	user := primitive.NewObjectID()
+	interview := &models.Interview{
+		ID:            primitive.NewObjectID(),
+		InterviewerID: primitive.NewObjectID(),
+		Guests: []models.InterviewGuest{
+			{Name: "Someone Else", Email: "else@example.com"},
+			{Name: "Export User", Email: "me@example.com", Interviewee: true, ClaimedBy: &user},
+		},
+	}
+
+	export := newInterviewExport(interview, user)
+	if export["role"] != "guest" {
+		t.Errorf("role = %v, want guest", export["role"])
+	}
+	raw, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "me@example.com") || strings.Contains(string(raw), "else@example.com") {
+		t.Errorf("export should hold only the user's own invitation: %s", raw)
+	}
+}
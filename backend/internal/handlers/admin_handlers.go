@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	adminDefaultPageSize = 50
+	adminMaxPageSize     = 200
+)
+
+// BootstrapAdmins grants the admin role to the verified accounts listed in
+// ADMIN_EMAILS. Unverified accounts are skipped so that nobody can claim admin
+// by registering a listed address first.
+func BootstrapAdmins(ctx context.Context) error {
+	emails := config.AppConfig.AdminEmails
+	if len(emails) == 0 {
+		return nil
+	}
+	result, err := database.GetCollection("users").UpdateMany(ctx,
+		bson.M{
+			"email":                      bson.M{"$in": emails},
+			"email_verification_pending": bson.M{"$ne": true},
+			"availableRoles":             bson.M{"$ne": "admin"},
+		},
+		bson.M{
+			"$addToSet": bson.M{"availableRoles": "admin"},
+			"$set":      bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Granted admin role to %d account(s) from ADMIN_EMAILS", result.ModifiedCount)
+	}
+	return nil
+}
+
+// recordAudit writes an entry to the audit log for an action by the current
+// admin. Failures are logged but do not undo the action.
+func recordAudit(c *gin.Context, action, targetType, targetID string, details map[string]interface{}) {
+	actorID := c.MustGet("userObjectID").(primitive.ObjectID)
+	entry := models.AuditLog{
+		ID:         primitive.NewObjectID(),
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		IP:         c.ClientIP(),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var actor models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": actorID}).Decode(&actor); err == nil {
+		entry.ActorEmail = actor.Email
+	}
+	if _, err := database.GetCollection("audit_logs").InsertOne(context.Background(), entry); err != nil {
+		log.Printf("Error writing audit log entry %s for admin %s: %v", action, actorID.Hex(), err)
+	}
+}
+
+// parsePage reads ?page= (1-based) and ?limit= and returns skip and limit.
+func parsePage(c *gin.Context) (int64, int64) {
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(adminDefaultPageSize)), 10, 64)
+	if err != nil || limit < 1 {
+		limit = adminDefaultPageSize
+	}
+	if limit > adminMaxPageSize {
+		limit = adminMaxPageSize
+	}
+	return (page - 1) * limit, limit
+}
+
+func newAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		UserResponse: newUserResponse(user),
+		Banned:       user.Banned,
+		BannedAt:     user.BannedAt,
+		BannedBy:     user.BannedBy,
+		BannedReason: user.BannedReason,
+	}
+}
+
+// AdminListUsersHandler lists and searches users. Filters: q (name or email
+// substring), role and banned=true|false. Results are paginated.
+func AdminListUsersHandler(c *gin.Context) {
+	filter := bson.M{}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+	if role := c.Query("role"); role != "" {
+		filter["availableRoles"] = role
+	}
+	switch c.Query("banned") {
+	case "true":
+		filter["banned"] = true
+	case "false":
+		filter["banned"] = bson.M{"$ne": true}
+	}
+
+	skip, limit := parsePage(c)
+	userCollection := database.GetCollection("users")
+	total, err := userCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		log.Printf("Error counting users for admin search: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+	cursor, err := userCollection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetSkip(skip).SetLimit(limit),
+	)
+	if err != nil {
+		log.Printf("Error searching users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+	var users []models.User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		log.Printf("Error decoding users for admin search: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	response := make([]models.AdminUserResponse, 0, len(users))
+	for i := range users {
+		response = append(response, newAdminUserResponse(&users[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"users": response, "total": total})
+}
+
+// AdminGetUserHandler returns one user with moderation details.
+func AdminGetUserHandler(c *gin.Context) {
+	user, ok := loadAdminTargetUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// AdminBanUserHandler bans a user and ends all of their sessions, tokens and sockets.
+func AdminBanUserHandler(c *gin.Context) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.BanUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	user, ok := loadAdminTargetUser(c)
+	if !ok {
+		return
+	}
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot ban yourself"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"banned":        true,
+			"banned_at":     now,
+			"banned_by":     adminID,
+			"banned_reason": input.Reason,
+			"updatedAt":     now,
+		}},
+	)
+	if err != nil {
+		log.Printf("Error banning user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+		return
+	}
+	if err := revokeAllSessions(context.Background(), user.ID); err != nil {
+		// The ban alone already blocks every token, so this is not fatal
+		log.Printf("Error revoking sessions of banned user %s: %v", user.Email, err)
+	}
+
+	recordAudit(c, "user.ban", "user", user.ID.Hex(), map[string]interface{}{"email": user.Email, "reason": input.Reason})
+	log.Printf("Admin %s banned user %s", adminID.Hex(), user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "User banned"})
+}
+
+// AdminUnbanUserHandler lifts a ban. The user has to log in again.
+func AdminUnbanUserHandler(c *gin.Context) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	user, ok := loadAdminTargetUser(c)
+	if !ok {
+		return
+	}
+	if !user.Banned {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is not banned"})
+		return
+	}
+
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$unset": bson.M{"banned": "", "banned_at": "", "banned_by": "", "banned_reason": ""},
+			"$set":   bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		log.Printf("Error unbanning user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban user"})
+		return
+	}
+
+	recordAudit(c, "user.unban", "user", user.ID.Hex(), map[string]interface{}{"email": user.Email})
+	log.Printf("Admin %s unbanned user %s", adminID.Hex(), user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "User unbanned"})
+}
+
+// AdminUpdateUserRolesHandler replaces a user's available roles. If the primary
+// role is removed, the first remaining non-admin role becomes primary.
+func AdminUpdateUserRolesHandler(c *gin.Context) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.UpdateUserRolesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	user, ok := loadAdminTargetUser(c)
+	if !ok {
+		return
+	}
+
+	roles := make([]string, 0, len(input.Roles))
+	seen := make(map[string]bool)
+	primary := ""
+	for _, role := range input.Roles {
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		roles = append(roles, role)
+		if role != "admin" && (primary == "" || role == user.Role) {
+			primary = role
+		}
+	}
+	if primary == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Users need at least one of the interviewer or interviewee roles"})
+		return
+	}
+	if user.ID == adminID && !seen["admin"] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot remove your own admin role"})
+		return
+	}
+
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"availableRoles": roles, "role": primary, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error updating roles of user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roles"})
+		return
+	}
+
+	recordAudit(c, "user.roles.update", "user", user.ID.Hex(), map[string]interface{}{
+		"email": user.Email,
+		"from":  user.AvailableRoles,
+		"to":    roles,
+	})
+	log.Printf("Admin %s changed roles of %s from %v to %v", adminID.Hex(), user.Email, user.AvailableRoles, roles)
+	user.AvailableRoles = roles
+	user.Role = primary
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// AdminCancelInterviewHandler cancels a scheduled or in-progress interview and
+// closes its interview room.
+func AdminCancelInterviewHandler(c *gin.Context) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.CancelInterviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	interviewID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+
+	var interview models.Interview
+	err = database.GetCollection("interviews").FindOne(context.Background(), bson.M{"_id": interviewID}).Decode(&interview)
+	if err == nil {
+		_, err = changeInterviewStatus(context.Background(), &interview, "admin-cancel", adminID, "admin", input.Reason)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments || err == errInvalidTransition {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No scheduled or in-progress interview with this ID"})
+			return
+		}
+		log.Printf("Error cancelling interview %s: %v", interviewID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel interview"})
+		return
+	}
+
+	recordAudit(c, "interview.cancel", "interview", interviewID.Hex(), map[string]interface{}{
+		"previous_status": interview.Status,
+		"reason":          input.Reason,
+	})
+	log.Printf("Admin %s cancelled interview %s", adminID.Hex(), interviewID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Interview cancelled"})
+}
+
+// AdminListAuditLogsHandler shows the audit history, newest first. Filters:
+// actor (user ID), action and target (ID).
+func AdminListAuditLogsHandler(c *gin.Context) {
+	filter := bson.M{}
+	if actor := c.Query("actor"); actor != "" {
+		actorID, err := primitive.ObjectIDFromHex(actor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID format"})
+			return
+		}
+		filter["actor_id"] = actorID
+	}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if target := c.Query("target"); target != "" {
+		filter["target_id"] = target
+	}
+
+	skip, limit := parsePage(c)
+	cursor, err := database.GetCollection("audit_logs").Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetSkip(skip).SetLimit(limit),
+	)
+	if err != nil {
+		log.Printf("Error listing audit logs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
+		return
+	}
+	entries := []models.AuditLog{}
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		log.Printf("Error decoding audit logs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// loadAdminTargetUser loads the user named by the :userId path parameter,
+// responding with 400/404/500 itself if that fails.
+func loadAdminTargetUser(c *gin.Context) (*models.User, bool) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		log.Printf("Error finding user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// UnlockUserLoginHandler clears a user's failed login counter, lifting any
+// backoff or lockout on their account.
+func UnlockUserLoginHandler(c *gin.Context) {
+	user, ok := loadAdminTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if err := accountLoginLimiter().Reset(context.Background(), loginAccountKey(user.Email)); err != nil {
+		log.Printf("Error unlocking login for %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	recordAudit(c, "user.unlock", "user", user.ID.Hex(), map[string]interface{}{"email": user.Email})
+	adminID, _ := c.Get("userID")
+	log.Printf("Admin %s unlocked login for user %s", adminID, user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
+
+// UnlockIPLoginHandler clears the failed login counter for a client IP address.
+func UnlockIPLoginHandler(c *gin.Context) {
+	ip := net.ParseIP(c.Param("ip"))
+	if ip == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP address"})
+		return
+	}
+
+	if err := ipLoginLimiter().Reset(context.Background(), ip.String()); err != nil {
+		log.Printf("Error unlocking login for IP %s: %v", ip, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock IP address"})
+		return
+	}
+
+	recordAudit(c, "ip.unlock", "ip", ip.String(), nil)
+	adminID, _ := c.Get("userID")
+	log.Printf("Admin %s unlocked login for IP %s", adminID, ip)
+	c.JSON(http.StatusOK, gin.H{"message": "IP address unlocked"})
+}
@@ -4,14 +4,15 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strconv"
 	// "strings"
 	"time"
 
+	"mock-orbit/backend/internal/auth"
 	"mock-orbit/backend/internal/config"
 	"mock-orbit/backend/internal/database"
 	"mock-orbit/backend/internal/models"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -42,7 +43,7 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(input.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process registration"})
@@ -50,16 +51,20 @@ func RegisterHandler(c *gin.Context) {
 	}
 
 	availableRoles := []string{input.Role}
+	now := time.Now().UTC()
 
 	newUser := models.User{
-		ID:             primitive.NewObjectID(),
-		Name:           input.Name,
-		Email:          input.Email,
-		Password:       string(hashedPassword),
-		Role:           input.Role,
-		AvailableRoles: availableRoles,
-		CreatedAt:      time.Now().UTC(),
-		UpdatedAt:      time.Now().UTC(),
+		ID:                       primitive.NewObjectID(),
+		Name:                     input.Name,
+		Email:                    input.Email,
+		Password:                 hashedPassword,
+		Role:                     input.Role,
+		AvailableRoles:           availableRoles,
+		SearchKeys:               userSearchKeys(input.Name),
+		EmailVerificationPending: true,
+		VerificationEmailSentAt:  &now,
+		CreatedAt:                now,
+		UpdatedAt:                now,
 	}
 
 	_, err = userCollection.InsertOne(context.Background(), newUser)
@@ -69,8 +74,13 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
+	// The account exists even if the email fails; the user can request a resend
+	if err := sendVerificationEmail(context.Background(), &newUser); err != nil {
+		log.Printf("Error sending verification email to %s: %v", newUser.Email, err)
+	}
+
 	log.Printf("User registered successfully: %s, Role: %s", newUser.Email, newUser.Role)
-	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully. Please check your email to verify your address."})
 }
 
 // --- User Login Handler ---
@@ -85,10 +95,17 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// Unknown emails are throttled like real ones so lockouts don't reveal which exist
+	accountKey := loginAccountKey(input.Email)
+	if !checkLoginThrottle(c, accountKey) {
+		return
+	}
+
 	err := userCollection.FindOne(context.Background(), bson.M{"email": input.Email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			log.Printf("Login attempt failed for non-existent email: %s", input.Email)
+			recordLoginFailure(c, accountKey)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 			return
 		}
@@ -100,37 +117,300 @@ func LoginHandler(c *gin.Context) {
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
 		log.Printf("Login attempt failed for email %s due to incorrect password", input.Email)
+		recordLoginFailure(c, accountKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID.Hex(),
-		"email":   user.Email,
-		"roles":   user.AvailableRoles,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
-	})
+	completeLogin(c, &user)
+}
+
+// completeLogin finishes a successful first-factor login (password or OIDC).
+// With 2FA enabled this only earns a short-lived challenge, which
+// POST /auth/mfa/verify exchanges for a session.
+func completeLogin(c *gin.Context, user *models.User) {
+	if rejectBanned(c, user) {
+		return
+	}
+	if user.TOTPEnabled {
+		mfaToken, err := auth.NewActionToken(auth.PurposeMFAPending, user.ID, mfaPendingTTL, nil)
+		if err != nil {
+			log.Printf("Error issuing MFA challenge for user %s: %v", user.Email, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+			return
+		}
+		log.Printf("First factor accepted for %s, awaiting second factor", user.Email)
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int64(mfaPendingTTL.Seconds()),
+		})
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+	tokens, err := startSession(c, user)
 	if err != nil {
-		log.Printf("Error signing token for user %s: %v", user.Email, err)
+		log.Printf("Error issuing tokens for user %s: %v", user.Email, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate login token"})
 		return
 	}
 
+	resetLoginFailures(loginAccountKey(user.Email))
 	log.Printf("User logged in successfully: %s", user.Email)
+	tokens["user"] = newUserResponse(user)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// --- Refresh Token Handler ---
+// RefreshHandler exchanges a refresh token for a new access/refresh token pair.
+// Refresh tokens are single-use: presenting one that was already rotated is
+// treated as theft and revokes the session they belong to.
+func RefreshHandler(c *gin.Context) {
+	refreshCollection := database.GetCollection("refresh_tokens")
+	userCollection := database.GetCollection("users")
+	var input models.RefreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	tokenHash := auth.HashToken(input.RefreshToken)
+
+	// Atomically claim the token so two concurrent refreshes cannot both succeed
+	var current models.RefreshToken
+	err := refreshCollection.FindOneAndUpdate(context.Background(),
+		bson.M{
+			"token_hash": tokenHash,
+			"used_at":    nil,
+			"revoked_at": nil,
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+	).Decode(&current)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error claiming refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+			return
+		}
+
+		// Unknown, expired, revoked or already rotated. Reuse of a rotated token means
+		// it was copied, so revoke the whole session.
+		var previous models.RefreshToken
+		if findErr := refreshCollection.FindOne(context.Background(), bson.M{"token_hash": tokenHash}).Decode(&previous); findErr == nil && previous.UsedAt != nil {
+			log.Printf("SECURITY: Refresh token reuse detected for user %s (session %s). Revoking session.", previous.UserID.Hex(), previous.FamilyID.Hex())
+			if _, revokeErr := revokeSession(context.Background(), previous.UserID, previous.FamilyID); revokeErr != nil {
+				log.Printf("Error revoking session %s: %v", previous.FamilyID.Hex(), revokeErr)
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": current.UserID}).Decode(&user); err != nil {
+		log.Printf("User %s for refresh token not found: %v", current.UserID.Hex(), err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User associated with token not found"})
+		return
+	}
+
+	if rejectBanned(c, &user) {
+		return
+	}
+
+	// Refresh token families from before sessions existed get a session on
+	// their first refresh; revoked sessions stay revoked.
+	if err := auth.StartSession(context.Background(), current.FamilyID, user.ID, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		log.Printf("Error recording session %s: %v", current.FamilyID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	if _, err := auth.ActiveSession(context.Background(), current.FamilyID, user.ID); err != nil {
+		if err != auth.ErrRevokedToken {
+			log.Printf("Error loading session %s: %v", current.FamilyID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	tokens, err := issueTokenPair(context.Background(), &user, current.FamilyID)
+	if err != nil {
+		log.Printf("Error issuing refreshed tokens for user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	log.Printf("Refreshed tokens for user %s", user.Email)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// --- Logout Handlers ---
+// LogoutHandler ends the session the access token belongs to: the token itself
+// is revoked immediately, along with the session's refresh tokens. Interview-room
+// sockets opened from the session are closed.
+func LogoutHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	sessionID := c.MustGet("sessionID").(primitive.ObjectID)
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.MustGet("tokenExpiresAt").(time.Time)
 
-	userResponse := models.User{
-		ID:                user.ID,
-		Name:              user.Name,
-		Email:             user.Email,
-		Role:              user.Role,
-		AvailableRoles:    user.AvailableRoles,
-		ProfilePictureURL: user.ProfilePictureURL,
-		CreatedAt:         user.CreatedAt,
-		UpdatedAt:         user.UpdatedAt,
-	}
-	c.JSON(http.StatusOK, gin.H{"token": tokenString, "user": userResponse})
+	if err := auth.RevokeToken(context.Background(), tokenID, userID, expiresAt); err != nil {
+		log.Printf("Error revoking token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	if _, err := revokeSession(context.Background(), userID, sessionID); err != nil {
+		log.Printf("Error revoking session %s on logout: %v", sessionID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	log.Printf("User %s logged out (session %s revoked)", userID.Hex(), sessionID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAllHandler invalidates every access and refresh token issued to the user
+// and closes all of their interview-room sockets.
+func LogoutAllHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	if err := revokeAllSessions(context.Background(), userID); err != nil {
+		log.Printf("Error revoking all sessions for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out of all sessions"})
+		return
+	}
+
+	log.Printf("User %s logged out of all sessions", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+// JWKSHandler publishes the public keys that verify tokens issued by this API.
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(auth.JWKSMaxAge.Seconds())))
+	c.JSON(http.StatusOK, auth.JWKS())
+}
+
+// revokeAllSessions invalidates all of a user's tokens and sessions, including
+// personal access tokens, and disconnects their sockets.
+func revokeAllSessions(ctx context.Context, userID primitive.ObjectID) error {
+	if err := auth.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := auth.RevokeAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := auth.RevokeAllPATsForUser(ctx, userID); err != nil {
+		return err
+	}
+	_, err := database.GetCollection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	disconnectUser(userID.Hex())
+	return nil
+}
+
+// hashPassword returns the bcrypt hash stored for a password.
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// rejectBanned responds with 403 if an admin has banned the user.
+func rejectBanned(c *gin.Context, user *models.User) bool {
+	if !user.Banned {
+		return false
+	}
+	log.Printf("Login refused for banned user %s", user.Email)
+	c.JSON(http.StatusForbidden, gin.H{"error": "This account has been suspended", "code": "account_banned"})
+	return true
+}
+
+// startSession records a new session for a completed login and issues its first token pair.
+func startSession(c *gin.Context, user *models.User) (gin.H, error) {
+	sessionID := primitive.NewObjectID()
+	if err := auth.StartSession(context.Background(), sessionID, user.ID, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		return nil, err
+	}
+	return issueTokenPair(context.Background(), user, sessionID)
+}
+
+// issueTokenPair stores a new refresh token for the session and signs a new
+// access token in the session's active role and organization.
+func issueTokenPair(ctx context.Context, user *models.User, sessionID primitive.ObjectID) (gin.H, error) {
+	rawRefresh, refreshHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	record := models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: refreshHash,
+		ExpiresAt: now.Add(config.AppConfig.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+	if _, err := database.GetCollection("refresh_tokens").InsertOne(ctx, record); err != nil {
+		return nil, err
+	}
+	session, err := auth.RenewSession(ctx, sessionID, record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := issueAccessToken(user, sessionID, session.ActiveRole, session.ActiveOrgID)
+	if err != nil {
+		return nil, err
+	}
+	tokens["refresh_token"] = rawRefresh
+	return tokens, nil
+}
+
+// issueAccessToken signs an access token for the session. The requested role
+// falls back to the user's default if they don't hold it, and the requested
+// organization to the public space if they are no longer a member.
+func issueAccessToken(user *models.User, sessionID primitive.ObjectID, requestedRole string, requestedOrg *primitive.ObjectID) (gin.H, error) {
+	activeRole := auth.ResolveActiveRole(user, requestedRole)
+	activeOrg := auth.ResolveActiveOrg(user, requestedOrg)
+	accessToken, accessExpiresAt, err := auth.NewAccessToken(user, sessionID, activeRole, activeOrg)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"token":         accessToken,
+		"expires_in":    int64(time.Until(accessExpiresAt).Seconds()),
+		"active_role":   activeRole,
+		"active_org_id": activeOrg,
+	}, nil
+}
+
+// revokeSession revokes one session and every refresh token issued for it, and
+// closes its sockets. It reports false if the user has no such active session.
+func revokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (bool, error) {
+	revoked, err := auth.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return false, err
+	}
+	_, err = database.GetCollection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"family_id": sessionID, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	disconnectSession(sessionID.Hex())
+	return revoked, nil
 }
 
 // --- Create Interview Handler ---
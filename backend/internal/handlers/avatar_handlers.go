@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/blobstore"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/imaging"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Uploads are decoded into memory, so their dimensions are capped too
+	maxAvatarPixels = 40_000_000
+	// Size of the variant ProfilePictureURL points at
+	defaultAvatarSize = 256
+)
+
+// avatarSizes are the square variants generated for every upload, in pixels.
+var avatarSizes = []int{64, 256}
+
+// avatarUploadTypes are the accepted upload types, as sniffed from the content.
+var avatarUploadTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// UploadAvatarHandler sets the current user's profile picture from a
+// multipart upload (field "avatar"). The image is checked by content, cropped
+// to a square and re-encoded at every size in avatarSizes, which also strips
+// EXIF and other metadata. The previous upload, if any, is deleted.
+func UploadAvatarHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	maxBytes := int64(config.AppConfig.AvatarMaxBytes)
+
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64<<10)
+	file, header, err := c.Request.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Profile pictures can be at most %d bytes", maxBytes)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload the picture as the \"avatar\" field of a multipart form"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Profile pictures can be at most %d bytes", maxBytes)})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		log.Printf("Error reading avatar upload for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Profile pictures can be at most %d bytes", maxBytes)})
+		return
+	}
+
+	// Trust the bytes, not the client's Content-Type
+	if !avatarUploadTypes[http.DetectContentType(data)] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Profile pictures must be JPEG, PNG or GIF images"})
+		return
+	}
+	img, format, err := imaging.Decode(data, maxAvatarPixels)
+	if err != nil {
+		if err == imaging.ErrTooLarge {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The image dimensions are too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The uploaded file is not a valid image"})
+		return
+	}
+
+	// Photos stay JPEG; anything else becomes PNG to keep transparency
+	avatar := models.Avatar{Format: "png", UploadedAt: time.Now().UTC()}
+	encode := imaging.EncodePNG
+	if format == "jpeg" {
+		avatar.Format = "jpg"
+		encode = imaging.EncodeJPEG
+	}
+	version := make([]byte, 8)
+	if _, err := rand.Read(version); err != nil {
+		log.Printf("Error generating avatar version for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile picture"})
+		return
+	}
+	avatar.Version = hex.EncodeToString(version)
+
+	for _, size := range avatarSizes {
+		variant, err := encode(imaging.Square(img, size))
+		if err == nil {
+			err = blobstore.Put(context.Background(), avatarKey(userID, &avatar, size), avatarContentType(avatar.Format), variant)
+		}
+		if err != nil {
+			log.Printf("Error storing %dpx avatar for user %s: %v", size, userID.Hex(), err)
+			deleteAvatarBlobs(context.Background(), userID, &avatar)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile picture"})
+			return
+		}
+	}
+
+	// The document from before the update says which upload was replaced
+	var user models.User
+	pictureURL := avatarURL(userID, &avatar)
+	err = userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"avatar": avatar, "profile_picture_url": pictureURL, "updatedAt": avatar.UploadedAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&user)
+	if err != nil {
+		log.Printf("Error saving avatar for user %s: %v", userID.Hex(), err)
+		deleteAvatarBlobs(context.Background(), userID, &avatar)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile picture"})
+		return
+	}
+	if user.Avatar != nil {
+		deleteAvatarBlobs(context.Background(), userID, user.Avatar)
+	}
+
+	user.Avatar = &avatar
+	user.ProfilePictureURL = &pictureURL
+	user.UpdatedAt = avatar.UploadedAt
+	log.Printf("User %s uploaded a new profile picture", user.Email)
+	c.JSON(http.StatusOK, newUserResponse(&user))
+}
+
+// DeleteAvatarHandler removes the current user's profile picture.
+func DeleteAvatarHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	if err := removeAvatar(context.Background(), userID); err != nil {
+		log.Printf("Error removing avatar of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove profile picture"})
+		return
+	}
+
+	var user models.User
+	err := userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{"$unset": bson.M{"profile_picture_url": ""}, "$set": bson.M{"updatedAt": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		log.Printf("Error clearing profile picture of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove profile picture"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(&user))
+}
+
+// GetAvatarHandler serves an uploaded profile picture. The file name is
+// "<userId>-<version>.<format>" as put in ProfilePictureURL; ?size= picks one
+// of avatarSizes. A new upload gets a new URL, so responses are cacheable for
+// good. Served without authentication so the URL works in <img> tags.
+func GetAvatarHandler(c *gin.Context) {
+	userID, avatar, ok := parseAvatarFileName(c.Param("file"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile picture not found"})
+		return
+	}
+	size := defaultAvatarSize
+	if raw := c.Query("size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || !isAvatarSize(n) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("size must be one of %v", avatarSizes)})
+			return
+		}
+		size = n
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, avatar.Version, size)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	blob, err := blobstore.Get(c.Request.Context(), avatarKey(userID, avatar, size))
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.Header("ETag", "")
+		if err == blobstore.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile picture not found"})
+			return
+		}
+		log.Printf("Error reading avatar %s: %v", c.Param("file"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load profile picture"})
+		return
+	}
+	defer blob.Body.Close()
+
+	c.DataFromReader(http.StatusOK, blob.Size, avatarContentType(avatar.Format), blob.Body, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// removeAvatar forgets the user's uploaded picture and deletes its files. It
+// leaves ProfilePictureURL for the caller to replace or clear.
+func removeAvatar(ctx context.Context, userID primitive.ObjectID) error {
+	var user models.User
+	err := database.GetCollection("users").FindOneAndUpdate(ctx,
+		bson.M{"_id": userID, "avatar": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"avatar": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before).SetProjection(bson.M{"avatar": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	deleteAvatarBlobs(ctx, userID, user.Avatar)
+	return nil
+}
+
+// deleteAvatarBlobs deletes every variant of an upload. Failures are logged:
+// a leftover file is unreachable once no user points at its version.
+func deleteAvatarBlobs(ctx context.Context, userID primitive.ObjectID, avatar *models.Avatar) {
+	if avatar == nil {
+		return
+	}
+	for _, size := range avatarSizes {
+		if err := blobstore.Delete(ctx, avatarKey(userID, avatar, size)); err != nil {
+			log.Printf("Error deleting %dpx avatar of user %s: %v", size, userID.Hex(), err)
+		}
+	}
+}
+
+func avatarKey(userID primitive.ObjectID, avatar *models.Avatar, size int) string {
+	return fmt.Sprintf("avatars/%s/%s-%d.%s", userID.Hex(), avatar.Version, size, avatar.Format)
+}
+
+func avatarURL(userID primitive.ObjectID, avatar *models.Avatar) string {
+	return fmt.Sprintf("%s/api/v1/avatars/%s-%s.%s", config.AppConfig.PublicURL, userID.Hex(), avatar.Version, avatar.Format)
+}
+
+func avatarContentType(format string) string {
+	if format == "jpg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+func isAvatarSize(size int) bool {
+	for _, s := range avatarSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAvatarFileName splits "<userId>-<version>.<format>" from an avatar URL.
+func parseAvatarFileName(name string) (primitive.ObjectID, *models.Avatar, bool) {
+	base, format, ok := strings.Cut(name, ".")
+	if !ok || (format != "jpg" && format != "png") {
+		return primitive.NilObjectID, nil, false
+	}
+	userHex, version, ok := strings.Cut(base, "-")
+	if !ok {
+		return primitive.NilObjectID, nil, false
+	}
+	userID, err := primitive.ObjectIDFromHex(userHex)
+	if err != nil {
+		return primitive.NilObjectID, nil, false
+	}
+	if _, err := hex.DecodeString(version); err != nil || len(version) != 16 {
+		return primitive.NilObjectID, nil, false
+	}
+	return userID, &models.Avatar{Version: version, Format: format}, true
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindOptionalJSON binds the request body for endpoints whose input is all
+// optional, so they can be called without one. A body that is sent, including
+// a chunked one, must be valid: otherwise it responds 400 and returns false.
+func bindOptionalJSON(c *gin.Context, obj interface{}) bool {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return true
+	}
+	// A chunked request can still turn out to be empty
+	if err := c.ShouldBindJSON(obj); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindOptionalJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		body    io.Reader
+		chunked bool
+		ok      bool
+		note    string
+	}{
+		{"no body", nil, false, true, ""},
+		{"empty body", strings.NewReader(""), false, true, ""},
+		{"valid body", strings.NewReader(`{"note":"Welcome"}`), false, true, "Welcome"},
+		{"malformed body", strings.NewReader(`{"note":`), false, false, ""},
+		{"note too long", strings.NewReader(`{"note":"` + strings.Repeat("x", 1001) + `"}`), false, false, ""},
+		{"chunked empty body", strings.NewReader(""), true, true, ""},
+		{"chunked valid body", strings.NewReader(`{"note":"Welcome"}`), true, true, "Welcome"},
+		{"chunked malformed body", strings.NewReader(`{"note":`), true, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", tt.body)
+			if tt.chunked {
+				c.Request.ContentLength = -1
+			}
+
+			var input struct {
+				Note string `json:"note" binding:"max=1000"`
+			}
+			if ok := bindOptionalJSON(c, &input); ok != tt.ok {
+				t.Fatalf("bindOptionalJSON() = %v, want %v (response %d %s)", ok, tt.ok, w.Code, w.Body)
+			}
+			if !tt.ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", w.Code)
+				}
+				return
+			}
+			if input.Note != tt.note {
+				t.Errorf("note = %q, want %q", input.Note, tt.note)
+			}
+		})
+	}
+}
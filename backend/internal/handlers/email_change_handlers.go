@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RequestEmailChangeHandler starts changing the current user's email address.
+// A confirmation link goes to the new address and a notice with a link to
+// cancel goes to the old one. Nothing changes until the new address is
+// confirmed; requesting again replaces the pending address.
+func RequestEmailChangeHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.ChangeEmailInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	newEmail := strings.TrimSpace(input.NewEmail)
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for email change: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This is already your email address"})
+		return
+	}
+	// Accounts created through social login may have no password to confirm
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+			log.Printf("Email change rejected for user %s: password incorrect", userID.Hex())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+			return
+		}
+	}
+
+	count, err := userCollection.CountDocuments(context.Background(), bson.M{"email": newEmail})
+	if err != nil {
+		log.Printf("Error checking email availability for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address already registered"})
+		return
+	}
+
+	// Claim the send slot atomically, throttled like verification resends
+	interval := config.AppConfig.EmailVerificationResendInterval
+	now := time.Now().UTC()
+	err = userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{
+			"_id":   userID,
+			"email": user.Email,
+			"$or": []bson.M{
+				{"pending_email_requested_at": nil},
+				{"pending_email_requested_at": bson.M{"$lte": now.Add(-interval)}},
+			},
+		},
+		bson.M{"$set": bson.M{"pending_email": newEmail, "pending_email_requested_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			retryAfter := interval
+			if user.PendingEmailRequestedAt != nil {
+				retryAfter = time.Until(user.PendingEmailRequestedAt.Add(interval))
+			}
+			c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another email change"})
+			return
+		}
+		log.Printf("Error storing pending email for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+
+	if err := sendEmailChangeConfirmation(context.Background(), &user); err != nil {
+		log.Printf("Error sending email change confirmation for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send confirmation email"})
+		return
+	}
+	if err := sendEmailChangeNotice(context.Background(), &user, user.Email, newEmail, false); err != nil {
+		log.Printf("Error sending email change notice to %s: %v", user.Email, err)
+	}
+
+	log.Printf("User %s asked to change their email to %s", user.Email, newEmail)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "We sent a confirmation link to your new address. Your email changes once you open it.",
+		"pending_email": newEmail,
+	})
+}
+
+// ConfirmEmailChangeHandler completes an email change from the link sent to
+// the new address. The swap is conditional on both the old and the pending
+// address, so only the latest request can complete, and it bumps the token
+// version: access tokens carrying the old email stop working and clients pick
+// up the new one on their next refresh.
+func ConfirmEmailChangeHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	claims, userID, oldEmail, newEmail, ok := parseEmailChangeToken(c, auth.PurposeEmailChange)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	err := userCollection.FindOne(context.Background(), bson.M{"_id": userID, "email": oldEmail, "pending_email": newEmail}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Already confirmed, replaced by a newer request or cancelled
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation link"})
+			return
+		}
+		log.Printf("Error finding user %s for email change: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This confirmation link has already been used"})
+			return
+		}
+		log.Printf("Error consuming email change token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+
+	now := time.Now().UTC()
+	err = userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": userID, "email": oldEmail, "pending_email": newEmail},
+		bson.M{
+			"$set":   bson.M{"email": newEmail, "email_verified_at": now, "updatedAt": now},
+			"$unset": bson.M{"pending_email": "", "pending_email_requested_at": "", "email_verification_pending": ""},
+			"$inc":   bson.M{"token_version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			// Someone registered the address after the request was made
+			c.JSON(http.StatusConflict, gin.H{"error": "This email address is now used by another account"})
+		case err == mongo.ErrNoDocuments:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation link"})
+		default:
+			log.Printf("Error changing email of user %s: %v", userID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		}
+		return
+	}
+
+	if err := sendEmailChangeNotice(context.Background(), &user, oldEmail, newEmail, true); err != nil {
+		log.Printf("Error sending email change notice to %s: %v", oldEmail, err)
+	}
+	// Interviews joined through guest links sent to the new address become theirs
+	claimGuestHistory(context.Background(), &user)
+
+	log.Printf("User %s changed their email from %s to %s", userID.Hex(), oldEmail, newEmail)
+	c.JSON(http.StatusOK, gin.H{"message": "Email address changed. Use it the next time you log in."})
+}
+
+// RevertEmailChangeHandler undoes an email change from the link sent to the
+// old address. Before the change is confirmed it cancels it; afterwards it
+// swaps the old address back. Either way someone else may have the password,
+// so the account is logged out everywhere.
+func RevertEmailChangeHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	claims, userID, oldEmail, newEmail, ok := parseEmailChangeToken(c, auth.PurposeEmailRevert)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	err := userCollection.FindOne(context.Background(), bson.M{
+		"_id": userID,
+		"$or": []bson.M{
+			{"email": newEmail},
+			{"email": oldEmail, "pending_email": newEmail},
+		},
+	}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Already reverted, or the email has changed again since
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+			return
+		}
+		log.Printf("Error finding user %s to revert email change: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert email change"})
+		return
+	}
+
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This link has already been used"})
+			return
+		}
+		log.Printf("Error consuming email revert token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert email change"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_, err = userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID, "email": user.Email},
+		bson.M{
+			// Opening the link proves the old address works
+			"$set":   bson.M{"email": oldEmail, "email_verified_at": now, "updatedAt": now},
+			"$unset": bson.M{"pending_email": "", "pending_email_requested_at": "", "email_verification_pending": ""},
+		},
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Your previous email address is now used by another account. Please contact support."})
+			return
+		}
+		log.Printf("Error reverting email of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert email change"})
+		return
+	}
+
+	if err := revokeAllSessions(context.Background(), userID); err != nil {
+		log.Printf("Error revoking sessions after email revert for user %s: %v", userID.Hex(), err)
+	}
+
+	log.Printf("User %s reverted an email change to %s (was %s)", userID.Hex(), oldEmail, user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Your email address is " + oldEmail + " again and you have been logged out everywhere. If you did not ask for the change, reset your password now."})
+}
+
+// parseEmailChangeToken validates an email change or revert token from the
+// token query parameter and returns its claims. It writes the error response
+// and returns false if the token is invalid.
+func parseEmailChangeToken(c *gin.Context, purpose string) (jwt.MapClaims, primitive.ObjectID, string, string, bool) {
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		return nil, primitive.NilObjectID, "", "", false
+	}
+	claims, err := auth.ParseActionToken(purpose, tokenString)
+	if err != nil {
+		log.Printf("Email change link rejected: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+		return nil, primitive.NilObjectID, "", "", false
+	}
+	userID, err := auth.ClaimObjectID(claims, "user_id")
+	oldEmail, _ := claims["email"].(string)
+	newEmail, _ := claims["new_email"].(string)
+	if err != nil || oldEmail == "" || newEmail == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+		return nil, primitive.NilObjectID, "", "", false
+	}
+	return claims, userID, oldEmail, newEmail, true
+}
+
+// sendEmailChangeConfirmation mails the pending address a link that completes the change.
+func sendEmailChangeConfirmation(ctx context.Context, user *models.User) error {
+	token, err := auth.NewActionToken(auth.PurposeEmailChange, user.ID, config.AppConfig.EmailVerificationTTL, map[string]interface{}{
+		"email":     user.Email,
+		"new_email": user.PendingEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.PublicURL + "/api/v1/auth/email/confirm?token=" + url.QueryEscape(token)
+	return mailer.Send(ctx, mailer.Message{
+		To:      user.PendingEmail,
+		Subject: "Confirm your new Mock Orbit email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to use this address for your Mock Orbit account instead of %s:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this email.\n",
+			user.Name, user.Email, link, config.AppConfig.EmailVerificationTTL),
+	})
+}
+
+// sendEmailChangeNotice tells the old address about a requested or completed
+// email change, with a one-click link to undo it.
+func sendEmailChangeNotice(ctx context.Context, user *models.User, oldEmail, newEmail string, completed bool) error {
+	token, err := auth.NewActionToken(auth.PurposeEmailRevert, user.ID, config.AppConfig.EmailChangeRevertTTL, map[string]interface{}{
+		"email":     oldEmail,
+		"new_email": newEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.PublicURL + "/api/v1/auth/email/revert?token=" + url.QueryEscape(token)
+	what := "Someone asked to change the email address of your Mock Orbit account to " + newEmail + "."
+	if completed {
+		what = "The email address of your Mock Orbit account was changed to " + newEmail + "."
+	}
+	return mailer.Send(ctx, mailer.Message{
+		To:      oldEmail,
+		Subject: "Your Mock Orbit email address is changing",
+		Body: fmt.Sprintf("Hi %s,\n\n%s\n\nIf this was not you, open the link below to keep %s and log out every device:\n\n%s\n\nThe link works for %s.\n",
+			user.Name, what, oldEmail, link, config.AppConfig.EmailChangeRevertTTL),
+	})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxGuestsPerInterview caps outstanding guest links on one interview.
+const maxGuestsPerInterview = 10
+
+// errGuestNotAllowed is returned by authenticateGuest when the link is valid
+// but the guest may not join: the link was revoked or the interview is over.
+var errGuestNotAllowed = errors.New("guest may not join this interview")
+
+// CreateGuestLinkHandler invites someone without an account to an interview.
+// Only the interviewer can invite guests. The link is emailed to the guest and
+// also returned so the interviewer can share it directly.
+func CreateGuestLinkHandler(c *gin.Context) {
+	callerID := c.MustGet("userObjectID").(primitive.ObjectID)
+	interviewCollection := database.GetCollection("interviews")
+
+	interviewOID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+	var input models.GuestInviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var interview models.Interview
+	if err := interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID, "org_id": tenantID(c)}).Decode(&interview); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
+			return
+		}
+		log.Printf("Error finding interview %s for guest link: %v", interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest link"})
+		return
+	}
+	if interview.InterviewerID != callerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the interviewer can invite guests"})
+		return
+	}
+	if interview.Status != "scheduled" && interview.Status != "in_progress" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Guests can only be invited to scheduled or in-progress interviews"})
+		return
+	}
+	active := 0
+	for _, g := range interview.Guests {
+		if g.RevokedAt == nil && time.Now().Before(g.ExpiresAt) {
+			active++
+		}
+	}
+	if active >= maxGuestsPerInterview {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many active guest links for this interview. Revoke one first."})
+		return
+	}
+
+	guest := newInterviewGuest(&input, callerID, false)
+	result, err := interviewCollection.UpdateOne(context.Background(),
+		bson.M{"_id": interviewOID, "status": bson.M{"$in": []string{"scheduled", "in_progress"}}},
+		bson.M{
+			"$push": bson.M{"guests": guest},
+			"$set":  bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		log.Printf("Error adding guest to interview %s: %v", interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest link"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Guests can only be invited to scheduled or in-progress interviews"})
+		return
+	}
+
+	link, err := sendGuestLink(context.Background(), &interview, &guest)
+	if err != nil {
+		log.Printf("Error creating guest link for interview %s: %v", interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest link"})
+		return
+	}
+
+	log.Printf("User %s invited guest %s (%s) to interview %s", callerID.Hex(), guest.ID.Hex(), guest.Email, interviewOID.Hex())
+	c.JSON(http.StatusCreated, gin.H{"guest": guest, "link": link})
+}
+
+// RevokeGuestLinkHandler stops a guest link from working and disconnects the
+// guest if they are in the room.
+func RevokeGuestLinkHandler(c *gin.Context) {
+	callerID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	interviewOID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+	guestID, err := primitive.ObjectIDFromHex(c.Param("guestId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guest ID format"})
+		return
+	}
+
+	now := time.Now().UTC()
+	result, err := database.GetCollection("interviews").UpdateOne(context.Background(),
+		bson.M{
+			"_id":            interviewOID,
+			"interviewer_id": callerID,
+			"org_id":         tenantID(c),
+			"guests":         bson.M{"$elemMatch": bson.M{"id": guestID, "revoked_at": nil}},
+		},
+		bson.M{"$set": bson.M{"guests.$.revoked_at": now, "updatedAt": now}},
+	)
+	if err != nil {
+		log.Printf("Error revoking guest %s of interview %s: %v", guestID.Hex(), interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke guest link"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Guest link not found"})
+		return
+	}
+
+	clientID := guestClientID(guestID)
+	hub.DisconnectWhere(func(cl *Client) bool { return cl.UserID == clientID }, "Guest link revoked")
+
+	log.Printf("User %s revoked guest %s of interview %s", callerID.Hex(), guestID.Hex(), interviewOID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Guest link revoked"})
+}
+
+// ReissueGuestLinkHandler sends an existing guest a fresh link, for example
+// when the first one expired or got lost. The guest keeps their identity, so
+// it also works for a guest scheduled as the interviewee.
+func ReissueGuestLinkHandler(c *gin.Context) {
+	callerID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	interviewOID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+	guestID, err := primitive.ObjectIDFromHex(c.Param("guestId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guest ID format"})
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(config.AppConfig.GuestLinkTTL)
+	var interview models.Interview
+	err = database.GetCollection("interviews").FindOneAndUpdate(context.Background(),
+		bson.M{
+			"_id":            interviewOID,
+			"interviewer_id": callerID,
+			"org_id":         tenantID(c),
+			"status":         bson.M{"$in": []string{"scheduled", "in_progress"}},
+			"guests":         bson.M{"$elemMatch": bson.M{"id": guestID, "revoked_at": nil}},
+		},
+		bson.M{"$set": bson.M{"guests.$.expires_at": expiresAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&interview)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Guest link not found"})
+			return
+		}
+		log.Printf("Error reissuing guest link %s of interview %s: %v", guestID.Hex(), interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reissue guest link"})
+		return
+	}
+
+	for i := range interview.Guests {
+		guest := &interview.Guests[i]
+		if guest.ID != guestID {
+			continue
+		}
+		link, err := sendGuestLink(context.Background(), &interview, guest)
+		if err != nil {
+			log.Printf("Error reissuing guest link %s of interview %s: %v", guestID.Hex(), interviewOID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reissue guest link"})
+			return
+		}
+		log.Printf("User %s reissued the link of guest %s for interview %s", callerID.Hex(), guestID.Hex(), interviewOID.Hex())
+		c.JSON(http.StatusOK, gin.H{"guest": guest, "link": link})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Guest link not found"})
+}
+
+func newInterviewGuest(input *models.GuestInviteInput, invitedBy primitive.ObjectID, interviewee bool) models.InterviewGuest {
+	now := time.Now().UTC()
+	return models.InterviewGuest{
+		ID:          primitive.NewObjectID(),
+		Name:        strings.TrimSpace(input.Name),
+		Email:       strings.ToLower(strings.TrimSpace(input.Email)),
+		Interviewee: interviewee,
+		InvitedBy:   invitedBy,
+		InvitedAt:   now,
+		ExpiresAt:   now.Add(config.AppConfig.GuestLinkTTL),
+	}
+}
+
+// sendGuestLink signs a join link for the guest and emails it to them. The
+// link is returned even if the email could not be sent.
+func sendGuestLink(ctx context.Context, interview *models.Interview, guest *models.InterviewGuest) (string, error) {
+	token, err := auth.NewActionToken(auth.PurposeGuestJoin, guest.InvitedBy, time.Until(guest.ExpiresAt), map[string]interface{}{
+		"interview_id": interview.ID.Hex(),
+		"guest_id":     guest.ID.Hex(),
+	})
+	if err != nil {
+		return "", err
+	}
+	link := frontendLink("/interview-room/" + interview.ID.Hex() + "?guest=" + url.QueryEscape(token))
+
+	err = mailer.Send(ctx, mailer.Message{
+		To:      guest.Email,
+		Subject: "You're invited to a Mock Orbit interview",
+		Body: fmt.Sprintf("Hi %s,\n\n%s invited you to a mock interview on %s, scheduled for %s UTC. Join with the link below; no account is needed:\n\n%s\n\nThe link expires on %s UTC. If you later sign up with this email address, the interview will appear in your history.\n",
+			guest.Name, interview.InterviewerName, interview.Topic, interview.ScheduledTime.UTC().Format("2006-01-02 15:04"), link, guest.ExpiresAt.Format("2006-01-02 15:04")),
+	})
+	if err != nil {
+		log.Printf("Error sending guest link to %s: %v", guest.Email, err)
+	}
+	return link, nil
+}
+
+// authenticateGuest validates a guest link for the interview and returns the
+// interview and the guest. The link stops working when it expires, is revoked or the interview
+// is no longer scheduled or in progress.
+func authenticateGuest(ctx context.Context, tokenString string, interviewOID primitive.ObjectID) (*models.Interview, *models.InterviewGuest, error) {
+	claims, err := auth.ParseActionToken(auth.PurposeGuestJoin, tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+	claimedInterview, err := auth.ClaimObjectID(claims, "interview_id")
+	if err != nil || claimedInterview != interviewOID {
+		return nil, nil, auth.ErrInvalidToken
+	}
+	guestID, err := auth.ClaimObjectID(claims, "guest_id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var interview models.Interview
+	err = database.GetCollection("interviews").FindOne(ctx, bson.M{"_id": interviewOID, "guests.id": guestID}).Decode(&interview)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, auth.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if interview.Status != "scheduled" && interview.Status != "in_progress" {
+		return nil, nil, errGuestNotAllowed
+	}
+	for i := range interview.Guests {
+		guest := &interview.Guests[i]
+		if guest.ID != guestID {
+			continue
+		}
+		if guest.RevokedAt != nil {
+			return nil, nil, errGuestNotAllowed
+		}
+		return &interview, guest, nil
+	}
+	return nil, nil, auth.ErrInvalidToken
+}
+
+// recordGuestJoined stores when the guest first joined the interview room.
+func recordGuestJoined(ctx context.Context, interviewOID, guestID primitive.ObjectID) {
+	_, err := database.GetCollection("interviews").UpdateOne(ctx,
+		bson.M{"_id": interviewOID, "guests": bson.M{"$elemMatch": bson.M{"id": guestID, "joined_at": nil}}},
+		bson.M{"$set": bson.M{"guests.$.joined_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error recording that guest %s joined interview %s: %v", guestID.Hex(), interviewOID.Hex(), err)
+	}
+}
+
+// guestClientID is the participant ID a guest has in the interview room. It
+// can't collide with a user ID, which is a bare hex ObjectID.
+func guestClientID(guestID primitive.ObjectID) string {
+	return "guest_" + guestID.Hex()
+}
+
+// claimGuestHistory attaches interviews the user took part in as a guest to
+// their account. It must only be called once the user has proven they own
+// their email address. Guest interviewee slots become theirs.
+func claimGuestHistory(ctx context.Context, user *models.User) {
+	interviews := database.GetCollection("interviews")
+	email := strings.ToLower(strings.TrimSpace(user.Email))
+	now := time.Now().UTC()
+
+	// Fill the interviewee slot first, while the guest entry is still unclaimed
+	_, err := interviews.UpdateMany(ctx,
+		bson.M{
+			"interviewee_id": primitive.NilObjectID,
+			"interviewer_id": bson.M{"$ne": user.ID},
+			"guests":         bson.M{"$elemMatch": bson.M{"email": email, "interviewee": true, "claimed_by": nil}},
+		},
+		bson.M{"$set": bson.M{"interviewee_id": user.ID, "interviewee_name": user.Name, "updatedAt": now}},
+	)
+	if err != nil {
+		log.Printf("Error claiming guest interviewee slots for user %s: %v", user.Email, err)
+		return
+	}
+
+	result, err := interviews.UpdateMany(ctx,
+		bson.M{"guests": bson.M{"$elemMatch": bson.M{"email": email, "claimed_by": nil}}},
+		bson.M{"$set": bson.M{"guests.$[g].claimed_by": user.ID, "guests.$[g].claimed_at": now, "updatedAt": now}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"g.email": email, "g.claimed_by": nil}},
+		}),
+	)
+	if err != nil {
+		log.Printf("Error claiming guest history for user %s: %v", user.Email, err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("User %s claimed guest history in %d interviews", user.Email, result.ModifiedCount)
+	}
+}
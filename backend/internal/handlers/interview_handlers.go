@@ -45,11 +45,20 @@ func CreateInterviewHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interviewer ID format"})
 		return
 	}
-	intervieweeOID, err := primitive.ObjectIDFromHex(input.IntervieweeID)
-	if err != nil {
-		log.Printf("Invalid interviewee ID format: %s, error: %v", input.IntervieweeID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interviewee ID format"})
-		return
+	// A guest without an account can take the interviewee slot instead
+	intervieweeOID := primitive.NilObjectID
+	if input.Guest != nil {
+		if input.IntervieweeID != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either interviewee_id or guest, not both"})
+			return
+		}
+	} else {
+		intervieweeOID, err = primitive.ObjectIDFromHex(input.IntervieweeID)
+		if err != nil {
+			log.Printf("Invalid interviewee ID format: %s, error: %v", input.IntervieweeID, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interviewee ID format"})
+			return
+		}
 	}
 	log.Printf("Validated ObjectIDs: Interviewer=%s, Interviewee=%s", interviewerOID.Hex(), intervieweeOID.Hex())
 
@@ -60,6 +69,24 @@ func CreateInterviewHandler(c *gin.Context) {
 		return
 	}
 
+	// The caller takes the slot of the role they are acting in
+	callerOID := c.MustGet("userObjectID").(primitive.ObjectID)
+	activeRole := c.GetString("activeRole")
+	switch {
+	case activeRole == "interviewer" && callerOID != interviewerOID:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are acting as an interviewer, so you must be the interviewer of the interview", "code": "active_role_mismatch"})
+		return
+	case activeRole == "interviewee" && input.Guest != nil:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only interviewers can invite guests", "code": "active_role_mismatch"})
+		return
+	case activeRole == "interviewee" && callerOID != intervieweeOID:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are acting as an interviewee, so you must be the interviewee of the interview", "code": "active_role_mismatch"})
+		return
+	case activeRole != "interviewer" && activeRole != "interviewee":
+		c.JSON(http.StatusForbidden, gin.H{"error": "You need an interviewer or interviewee role to schedule interviews", "code": "active_role_required"})
+		return
+	}
+
 	// Prevent scheduling in the past
 	// Add a small buffer (e.g., 1 minute) to avoid issues with clock skew
 	if input.ScheduledTime.Before(time.Now().Add(-1 * time.Minute)) {
@@ -88,21 +115,66 @@ func CreateInterviewHandler(c *gin.Context) {
 	}
 	log.Printf("Successfully fetched interviewer: %s (%s)", interviewer.Name, interviewer.ID.Hex())
 
+	// The other participant must be in the tenant the caller is acting in.
+	// Users elsewhere are reported as not found so they can't be probed.
+	orgID := tenantID(c)
+	if interviewer.ID != callerOID && !inTenant(&interviewer, orgID) {
+		log.Printf("Interviewer %s is not in the caller's organization", interviewer.ID.Hex())
+		c.JSON(http.StatusNotFound, gin.H{"error": "Interviewer not found"})
+		return
+	}
 
-	log.Printf("Fetching interviewee details for ID: %s", input.IntervieweeID)
-	err = userCollection.FindOne(context.Background(), bson.M{"_id": intervieweeOID}).Decode(&interviewee)
-	if err != nil {
-		// Log the specific error and ID
-		log.Printf("Error finding interviewee with ID %s: %v", input.IntervieweeID, err)
-		if err == mongo.ErrNoDocuments {
+
+	var guests []models.InterviewGuest
+	if input.Guest != nil {
+		guest := newInterviewGuest(input.Guest, callerOID, true)
+		guests = append(guests, guest)
+		interviewee.Name = guest.Name
+	} else {
+		log.Printf("Fetching interviewee details for ID: %s", input.IntervieweeID)
+		err = userCollection.FindOne(context.Background(), bson.M{"_id": intervieweeOID}).Decode(&interviewee)
+		if err != nil {
+			// Log the specific error and ID
+			log.Printf("Error finding interviewee with ID %s: %v", input.IntervieweeID, err)
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Interviewee not found"})
+			} else {
+				// Indicate a server error for other DB issues
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve interviewee details", "details": err.Error()})
+			}
+			return
+		}
+		log.Printf("Successfully fetched interviewee: %s (%s)", interviewee.Name, interviewee.ID.Hex())
+
+		if interviewee.ID != callerOID && !inTenant(&interviewee, orgID) {
+			log.Printf("Interviewee %s is not in the caller's organization", interviewee.ID.Hex())
 			c.JSON(http.StatusNotFound, gin.H{"error": "Interviewee not found"})
-		} else {
-			// Indicate a server error for other DB issues
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve interviewee details", "details": err.Error()})
+			return
 		}
+
+		if !hasRole(&interviewee, "interviewee") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": interviewee.Name + " is not an interviewee"})
+			return
+		}
+
+		// Either participant may have blocked the other
+		blocked, err := isBlocked(context.Background(), interviewerOID, intervieweeOID)
+		if err != nil {
+			log.Printf("Error checking blocks between %s and %s: %v", interviewerOID.Hex(), intervieweeOID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule interview"})
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can't schedule an interview with this user", "code": "user_blocked"})
+			return
+		}
+	}
+
+	// The interviewer must hold the interviewer role
+	if !hasRole(&interviewer, "interviewer") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": interviewer.Name + " is not an interviewer"})
 		return
 	}
-	log.Printf("Successfully fetched interviewee: %s (%s)", interviewee.Name, interviewee.ID.Hex())
 
 
 	// Ensure schedule time is in UTC
@@ -117,8 +189,10 @@ func CreateInterviewHandler(c *gin.Context) {
 		ScheduledTime: scheduledTimeUTC,
 		Topic:          input.Topic,
 		Status:         "scheduled", // Initial status
+		OrgID:          orgID,
 		CreatedAt:      time.Now().UTC(),
 		UpdatedAt:      time.Now().UTC(),
+		Guests:         guests,
 	}
 
 	// Log the data just before inserting
@@ -142,17 +216,45 @@ func CreateInterviewHandler(c *gin.Context) {
 		interviewee.Name, intervieweeOID.Hex(),
 		input.Topic, newInterview.ID.Hex())
 
+	// Guests get their join link by email; it can be reissued from the guest endpoints
+	for i := range newInterview.Guests {
+		if _, err := sendGuestLink(context.Background(), &newInterview, &newInterview.Guests[i]); err != nil {
+			log.Printf("Error creating guest link for interview %s: %v", newInterview.ID.Hex(), err)
+		}
+	}
+
 	// Return the created interview object on success
 	c.JSON(http.StatusCreated, newInterview)
 }
 
+// claimedGuest reports whether the user joined the interview as a guest and
+// has since claimed it.
+func claimedGuest(interview *models.Interview, userID primitive.ObjectID) bool {
+	for _, guest := range interview.Guests {
+		if guest.ClaimedBy != nil && *guest.ClaimedBy == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRole reports whether role is one of the user's available roles.
+func hasRole(user *models.User, role string) bool {
+	for _, r := range user.AvailableRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUserInterviewsHandler retrieves interviews for a specific user based on role and status.
 func GetUserInterviewsHandler(c *gin.Context) {
 	interviewCollection := database.GetCollection("interviews") // Get collection inside handler
 	// userCollection := database.GetCollection("users")           // Get collection inside handler (Removed as not used directly)
 	userIDStr := c.Param("userId") // Get user ID from path parameter
 	// roleFilter := c.Query("role") // "interviewer" or "interviewee" - Used only for feedback status now
-	statusFilter := c.Query("status") // e.g., "scheduled", "completed", "cancelled"
+	statusFilter := c.Query("status") // e.g., "scheduled", "completed", "cancelled", "no_show"
 
 	userOID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
@@ -170,11 +272,12 @@ func GetUserInterviewsHandler(c *gin.Context) {
 		return
 	}
 
-	filter := bson.M{}
+	filter := bson.M{"org_id": tenantID(c)} // Only interviews in the organization the user is acting in
 	// Filter by the user's involvement
 	filter["$or"] = []bson.M{
 		{"interviewer_id": userOID},
 		{"interviewee_id": userOID},
+		{"guests.claimed_by": userOID}, // Joined as a guest before signing up
 	}
 
 	// Add status filtering if provided
@@ -184,7 +287,7 @@ func GetUserInterviewsHandler(c *gin.Context) {
 		for _, s := range statuses {
 			trimmed := strings.TrimSpace(s)
 			// Validate status values allowed by the filter
-			if trimmed != "" && (trimmed == "scheduled" || trimmed == "completed" || trimmed == "cancelled" || trimmed == "in_progress") {
+			if trimmed != "" && (trimmed == "scheduled" || trimmed == "completed" || trimmed == "cancelled" || trimmed == "in_progress" || trimmed == "no_show") {
 				validStatuses = append(validStatuses, trimmed)
 			}
 		}
@@ -200,7 +303,7 @@ func GetUserInterviewsHandler(c *gin.Context) {
 	// Removed default status filter - fetch all matching user involvement if no status specified
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{"scheduled_time", -1}}) // Sort by most recent first
+	findOptions.SetSort(bson.D{{Key: "scheduled_time", Value: -1}}) // Sort by most recent first
 
 	cursor, err := interviewCollection.Find(context.Background(), filter, findOptions)
 	if err != nil {
@@ -300,7 +403,7 @@ func GetInterviewDetailsHandler(c *gin.Context) {
 	}
 
 	var interview models.Interview
-	err = interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID}).Decode(&interview)
+	err = interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID, "org_id": tenantID(c)}).Decode(&interview)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
@@ -314,31 +417,37 @@ func GetInterviewDetailsHandler(c *gin.Context) {
 	// Security Check: Ensure the requesting user is part of this interview
 	requestingUserID, _ := c.Get("userObjectID")
 	reqOID := requestingUserID.(primitive.ObjectID)
-	if reqOID != interview.InterviewerID && reqOID != interview.IntervieweeID {
+	if reqOID != interview.InterviewerID && reqOID != interview.IntervieweeID && !claimedGuest(&interview, reqOID) {
 		log.Printf("Forbidden attempt: User %s trying to access interview %s", reqOID.Hex(), interviewIDStr)
 		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this interview"})
 		return
 	}
 
-	// Populate participant details using denormalized names first
-	interviewerInfo := models.UserInfo{ID: interview.InterviewerID, Name: interview.InterviewerName}
-	intervieweeInfo := models.UserInfo{ID: interview.IntervieweeID, Name: interview.IntervieweeName}
-
-	// Construct the response
-	response := models.InterviewResponse{
-		ID:            interview.ID,
-		Interviewer:   &interviewerInfo,
-		Interviewee:   &intervieweeInfo,
-		ScheduledTime: interview.ScheduledTime,
-		Topic:         interview.Topic,
-		Status:        interview.Status,
-		FeedbackStatus: determineFeedbackStatus(&interview, reqOID), // Determine based on requesting user
-	}
-
+	response := newInterviewResponse(&interview, reqOID)
 
 	log.Printf("Retrieved details for interview %s", interviewIDStr)
 	c.JSON(http.StatusOK, response)
 }
 
-// TODO: Add handlers for updating interview status (e.g., start, complete, cancel)
+// newInterviewResponse builds the detailed view of an interview for one of its
+// participants.
+func newInterviewResponse(interview *models.Interview, viewerID primitive.ObjectID) models.InterviewResponse {
+	// Populate participant details using denormalized names
+	response := models.InterviewResponse{
+		ID:                  interview.ID,
+		Interviewer:         &models.UserInfo{ID: interview.InterviewerID, Name: interview.InterviewerName},
+		Interviewee:         &models.UserInfo{ID: interview.IntervieweeID, Name: interview.IntervieweeName},
+		ScheduledTime:       interview.ScheduledTime,
+		Topic:               interview.Topic,
+		Status:              interview.Status,
+		FeedbackStatus:      determineFeedbackStatus(interview, viewerID), // Determine based on requesting user
+		StatusHistory:       interview.StatusHistory,
+		RescheduleProposals: interview.RescheduleProposals,
+	}
+	if viewerID == interview.InterviewerID {
+		response.Guests = interview.Guests // Only the interviewer manages guest links
+	}
+	return response
+}
+
 // TODO: Add handlers for managing feedback
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// How long before its scheduled time an interview can be started
+	interviewStartWindow = 15 * time.Minute
+	// How long after its scheduled time a missing participant counts as a no-show
+	interviewNoShowGrace = 10 * time.Minute
+)
+
+// interviewTransition is an action of the interview state machine.
+type interviewTransition struct {
+	From  []string // Statuses the action applies to
+	To    string
+	Roles []string // Who may take it: "interviewer", "interviewee" or "admin"
+}
+
+// interviewTransitions is the interview state machine:
+// scheduled → in_progress → completed, and scheduled → cancelled or no_show.
+// Admins can also cancel an interview that is under way.
+var interviewTransitions = map[string]interviewTransition{
+	"start":        {From: []string{"scheduled"}, To: "in_progress", Roles: []string{"interviewer", "interviewee"}},
+	"complete":     {From: []string{"in_progress"}, To: "completed", Roles: []string{"interviewer", "interviewee"}},
+	"cancel":       {From: []string{"scheduled"}, To: "cancelled", Roles: []string{"interviewer", "interviewee"}},
+	"no-show":      {From: []string{"scheduled"}, To: "no_show", Roles: []string{"interviewer", "interviewee"}},
+	"admin-cancel": {From: []string{"scheduled", "in_progress"}, To: "cancelled", Roles: []string{"admin"}},
+}
+
+var (
+	errTransitionNotAllowed = errors.New("interview: not allowed to make this status change")
+	errInvalidTransition    = errors.New("interview: status change not possible from the current status")
+	errTooEarly             = errors.New("interview: status change not possible yet")
+)
+
+// StartInterviewHandler marks a scheduled interview as under way. It can be
+// started from interviewStartWindow before its scheduled time.
+func StartInterviewHandler(c *gin.Context) { interviewStatusHandler(c, "start") }
+
+// CompleteInterviewHandler marks an interview that is under way as completed.
+func CompleteInterviewHandler(c *gin.Context) { interviewStatusHandler(c, "complete") }
+
+// CancelInterviewHandler cancels an interview that hasn't started.
+func CancelInterviewHandler(c *gin.Context) { interviewStatusHandler(c, "cancel") }
+
+// NoShowInterviewHandler records that the interview didn't take place because
+// a participant didn't turn up. It can be reported interviewNoShowGrace after
+// the scheduled time.
+func NoShowInterviewHandler(c *gin.Context) { interviewStatusHandler(c, "no-show") }
+
+// interviewStatusHandler takes a lifecycle action on behalf of a participant
+// and returns the updated interview.
+func interviewStatusHandler(c *gin.Context, action string) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.InterviewStatusInput
+	if !bindOptionalJSON(c, &input) {
+		return
+	}
+
+	interviewID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+
+	var interview models.Interview
+	err = database.GetCollection("interviews").FindOne(context.Background(), bson.M{"_id": interviewID, "org_id": tenantID(c)}).Decode(&interview)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
+			return
+		}
+		log.Printf("Error finding interview %s: %v", interviewID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update interview status"})
+		return
+	}
+	role := participantRole(&interview, userID)
+	if role == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this interview"})
+		return
+	}
+
+	updated, err := changeInterviewStatus(context.Background(), &interview, action, userID, role, input.Reason)
+	if err != nil {
+		switch err {
+		case errTransitionNotAllowed:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to change the status of this interview"})
+		case errInvalidTransition:
+			c.JSON(http.StatusConflict, gin.H{"error": "The interview is " + interview.Status + " and can't be changed this way", "code": "invalid_transition", "status": interview.Status})
+		case errTooEarly:
+			message := "The interview can be started from 15 minutes before its scheduled time"
+			if action == "no-show" {
+				message = "A no-show can be reported from 10 minutes after the scheduled time"
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": message, "code": "too_early", "scheduled_time": interview.ScheduledTime})
+		default:
+			log.Printf("Error updating status of interview %s: %v", interviewID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update interview status"})
+		}
+		return
+	}
+
+	log.Printf("User %s (%s) moved interview %s from %s to %s", userID.Hex(), role, interviewID.Hex(), interview.Status, updated.Status)
+	c.JSON(http.StatusOK, newInterviewResponse(updated, userID))
+}
+
+// changeInterviewStatus applies a state machine action to the interview as
+// loaded by the caller, records it in the status history and tells the
+// interview room. It fails with errInvalidTransition if the status changed
+// since the interview was loaded.
+func changeInterviewStatus(ctx context.Context, interview *models.Interview, action string, actorID primitive.ObjectID, actorRole, reason string) (*models.Interview, error) {
+	transition := interviewTransitions[action]
+	if !containsString(transition.Roles, actorRole) {
+		return nil, errTransitionNotAllowed
+	}
+	if !containsString(transition.From, interview.Status) {
+		return nil, errInvalidTransition
+	}
+	now := time.Now().UTC()
+	switch action {
+	case "start":
+		if now.Before(interview.ScheduledTime.Add(-interviewStartWindow)) {
+			return nil, errTooEarly
+		}
+	case "no-show":
+		if now.Before(interview.ScheduledTime.Add(interviewNoShowGrace)) {
+			return nil, errTooEarly
+		}
+	}
+
+	change := models.StatusChange{
+		From:      interview.Status,
+		To:        transition.To,
+		ActorID:   actorID,
+		ActorRole: actorRole,
+		Reason:    reason,
+		At:        now,
+	}
+	var updated models.Interview
+	err := database.GetCollection("interviews").FindOneAndUpdate(ctx,
+		bson.M{"_id": interview.ID, "status": interview.Status},
+		bson.M{
+			"$set":  bson.M{"status": change.To, "updatedAt": now},
+			"$push": bson.M{"status_history": change},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, errInvalidTransition
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	broadcastStatusChange(updated.ID.Hex(), change)
+	return &updated, nil
+}
+
+// broadcastStatusChange tells everyone in the interview room about a status
+// change. Once the interview is over the room is closed.
+func broadcastStatusChange(interviewID string, change models.StatusChange) {
+	hub.BroadcastMessage(interviewID, nil, map[string]interface{}{
+		"type":           "interview-status-changed",
+		"status":         change.To,
+		"previousStatus": change.From,
+		"actorId":        change.ActorID.Hex(),
+		"actorRole":      change.ActorRole,
+		"reason":         change.Reason,
+	})
+	if change.To == "in_progress" {
+		return
+	}
+	hub.BroadcastMessage(interviewID, nil, map[string]interface{}{"type": "interview-ended", "status": change.To})
+	reason := "Interview ended"
+	switch change.To {
+	case "cancelled":
+		reason = "Interview cancelled"
+	case "no_show":
+		reason = "Interview marked as a no-show"
+	}
+	go hub.CloseRoom(interviewID, reason)
+}
+
+// participantRole is the user's side of the interview, or "" if they are not
+// a participant.
+func participantRole(interview *models.Interview, userID primitive.ObjectID) string {
+	switch userID {
+	case interview.InterviewerID:
+		return "interviewer"
+	case interview.IntervieweeID:
+		return "interviewee"
+	}
+	return ""
+}
+
+// startInterviewOnJoin starts a scheduled interview once both sides are in
+// its room within the start window, so the room being used is reflected in
+// the status even if the client never calls the start endpoint. A participant
+// waiting alone leaves it scheduled, so the other side can still be recorded
+// as a no-show.
+func startInterviewOnJoin(ctx context.Context, interviewID primitive.ObjectID, client *Client) {
+	var interview models.Interview
+	err := database.GetCollection("interviews").FindOne(ctx, bson.M{"_id": interviewID, "status": "scheduled"}).Decode(&interview)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error loading interview %s to start it: %v", interviewID.Hex(), err)
+		}
+		return
+	}
+	present := hub.UserIDs(client.InterviewID)
+	intervieweePresent := present[interview.IntervieweeID.Hex()]
+	for _, guest := range interview.Guests {
+		if guest.Interviewee && present[guestClientID(guest.ID)] {
+			intervieweePresent = true
+		}
+	}
+	if !present[interview.InterviewerID.Hex()] || !intervieweePresent {
+		return
+	}
+
+	// Guests can't change the status, so a guest joining second starts it on the interviewer's behalf
+	actorID, role := interview.InterviewerID, "interviewer"
+	if client.GuestName == "" {
+		userID, err := primitive.ObjectIDFromHex(client.UserID)
+		if err != nil {
+			return
+		}
+		actorID, role = userID, participantRole(&interview, userID)
+		if role == "" {
+			return
+		}
+	}
+	_, err = changeInterviewStatus(ctx, &interview, "start", actorID, role, "Both participants joined the interview room")
+	switch err {
+	case nil:
+		log.Printf("Interview %s started as %s joined its room", interviewID.Hex(), client.UserID)
+	case errTooEarly, errInvalidTransition:
+		// Joined early, or the other participant started it first
+	default:
+		log.Printf("Error starting interview %s on join: %v", interviewID.Hex(), err)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// useUnreachableDB points the database at a server that isn't there, so a
+// status change that passes its checks fails on the write instead.
+func useUnreachableDB(t *testing.T) {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = client.Database("test")
+	t.Cleanup(func() {
+		database.DB = previous
+		client.Disconnect(context.Background())
+	})
+}
+
+func TestChangeInterviewStatus(t *testing.T) {
+	useUnreachableDB(t)
+	due := time.Now().Add(-interviewNoShowGrace - time.Minute) // Past both the start window and the no-show grace
+	later := time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		action    string
+		status    string
+		role      string
+		scheduled time.Time
+		err       error // nil when the change passes its checks and reaches the database
+	}{
+		{"interviewer starts", "start", "scheduled", "interviewer", due, nil},
+		{"interviewee starts", "start", "scheduled", "interviewee", due, nil},
+		{"interviewer completes", "complete", "in_progress", "interviewer", due, nil},
+		{"interviewee completes", "complete", "in_progress", "interviewee", due, nil},
+		{"interviewer cancels", "cancel", "scheduled", "interviewer", later, nil},
+		{"interviewee cancels", "cancel", "scheduled", "interviewee", later, nil},
+		{"interviewer reports a no-show", "no-show", "scheduled", "interviewer", due, nil},
+		{"interviewee reports a no-show", "no-show", "scheduled", "interviewee", due, nil},
+		{"admin cancels scheduled", "admin-cancel", "scheduled", "admin", later, nil},
+		{"admin cancels in progress", "admin-cancel", "in_progress", "admin", due, nil},
+
+		{"admin can't start", "start", "scheduled", "admin", due, errTransitionNotAllowed},
+		{"admin can't complete", "complete", "in_progress", "admin", due, errTransitionNotAllowed},
+		{"admin can't cancel as a participant", "cancel", "scheduled", "admin", later, errTransitionNotAllowed},
+		{"admin can't report a no-show", "no-show", "scheduled", "admin", due, errTransitionNotAllowed},
+		{"interviewer can't admin-cancel", "admin-cancel", "scheduled", "interviewer", later, errTransitionNotAllowed},
+		{"interviewee can't admin-cancel", "admin-cancel", "in_progress", "interviewee", due, errTransitionNotAllowed},
+		{"guest can't start", "start", "scheduled", "", due, errTransitionNotAllowed},
+		{"guest can't complete", "complete", "in_progress", "", due, errTransitionNotAllowed},
+		{"unknown action", "reopen", "completed", "interviewer", due, errTransitionNotAllowed},
+
+		{"start in progress", "start", "in_progress", "interviewer", due, errInvalidTransition},
+		{"start completed", "start", "completed", "interviewee", due, errInvalidTransition},
+		{"complete scheduled", "complete", "scheduled", "interviewer", due, errInvalidTransition},
+		{"complete cancelled", "complete", "cancelled", "interviewer", due, errInvalidTransition},
+		{"complete no-show", "complete", "no_show", "interviewee", due, errInvalidTransition},
+		{"cancel in progress", "cancel", "in_progress", "interviewee", due, errInvalidTransition},
+		{"cancel completed", "cancel", "completed", "interviewer", due, errInvalidTransition},
+		{"cancel no-show", "cancel", "no_show", "interviewer", due, errInvalidTransition},
+		{"no-show in progress", "no-show", "in_progress", "interviewer", due, errInvalidTransition},
+		{"no-show cancelled", "no-show", "cancelled", "interviewee", due, errInvalidTransition},
+		{"admin-cancel completed", "admin-cancel", "completed", "admin", due, errInvalidTransition},
+		{"admin-cancel cancelled", "admin-cancel", "cancelled", "admin", due, errInvalidTransition},
+
+		{"start before the window", "start", "scheduled", "interviewer", later, errTooEarly},
+		{"no-show before the grace", "no-show", "scheduled", "interviewee", time.Now(), errTooEarly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interview := &models.Interview{ID: primitive.NewObjectID(), Status: tt.status, ScheduledTime: tt.scheduled}
+			updated, err := changeInterviewStatus(context.Background(), interview, tt.action, primitive.NewObjectID(), tt.role, "")
+			if updated != nil {
+				t.Fatalf("changeInterviewStatus() updated %v without a database", updated)
+			}
+			if tt.err != nil {
+				if err != tt.err {
+					t.Errorf("changeInterviewStatus() = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			switch err {
+			case nil, errTransitionNotAllowed, errInvalidTransition, errTooEarly:
+				t.Errorf("changeInterviewStatus() = %v, want it to pass its checks and fail on the write", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer        = "Mock Orbit"
+	mfaPendingTTL     = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// EnrollTOTPHandler starts TOTP enrollment by generating a secret. 2FA is not
+// enabled until the user confirms a code from their authenticator app.
+func EnrollTOTPHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for TOTP enrollment: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	_, err = userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"totp_pending_secret": secret, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error storing pending TOTP secret for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	log.Printf("TOTP enrollment started for user %s", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTOTPHandler enables 2FA once the user proves their app generates valid
+// codes, and returns one-time recovery codes. The codes are only shown here.
+func ConfirmTOTPHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.TOTPCodeInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for TOTP confirmation: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No enrollment in progress"})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPPendingSecret, input.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Error generating recovery codes for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	result, err := userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID, "totp_pending_secret": user.TOTPPendingSecret},
+		bson.M{
+			"$set": bson.M{
+				"totp_enabled":        true,
+				"totp_secret":         user.TOTPPendingSecret,
+				"totp_last_used_step": step,
+				"recovery_codes":      hashes,
+				"updatedAt":           time.Now().UTC(),
+			},
+			"$unset": bson.M{"totp_pending_secret": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Error enabling TOTP for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Enrollment changed, please start again"})
+		return
+	}
+
+	log.Printf("TOTP enabled for user %s", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTPHandler turns 2FA off. It requires both the password and a current code.
+func DisableTOTPHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.DisableTOTPInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s to disable TOTP: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+	ok, err := checkSecondFactor(context.Background(), &user, input.Code, "")
+	if err != nil {
+		log.Printf("Error checking TOTP code for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	_, err = userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{
+			"$set": bson.M{"updatedAt": time.Now().UTC()},
+			"$unset": bson.M{
+				"totp_enabled":        "",
+				"totp_secret":         "",
+				"totp_pending_secret": "",
+				"totp_last_used_step": "",
+				"recovery_codes":      "",
+			},
+		},
+	)
+	if err != nil {
+		log.Printf("Error disabling TOTP for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	log.Printf("TOTP disabled for user %s", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodesHandler replaces all recovery codes after checking a current TOTP code.
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.TOTPCodeInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s to regenerate recovery codes: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	ok, err := checkSecondFactor(context.Background(), &user, input.Code, "")
+	if err != nil {
+		log.Printf("Error checking TOTP code for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Error generating recovery codes for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+	_, err = userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"recovery_codes": hashes, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error storing recovery codes for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	log.Printf("Recovery codes regenerated for user %s", userID.Hex())
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// VerifyMFAHandler completes a login for a user with 2FA enabled by exchanging
+// the mfa_pending challenge token plus a TOTP or recovery code for a session.
+func VerifyMFAHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	var input models.MFAVerifyInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	if (input.Code == "") == (input.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either a code or a recovery code"})
+		return
+	}
+
+	claims, err := auth.ParseActionToken(auth.PurposeMFAPending, input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge. Please log in again."})
+		return
+	}
+	userID, err := auth.ClaimObjectID(claims, "user_id")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge. Please log in again."})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge. Please log in again."})
+			return
+		}
+		log.Printf("Error finding user %s for MFA verification: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+
+	if rejectBanned(c, &user) {
+		return
+	}
+
+	// Wrong codes count against the same counters as wrong passwords
+	accountKey := loginAccountKey(user.Email)
+	if !checkLoginThrottle(c, accountKey) {
+		return
+	}
+
+	ok, err := checkSecondFactor(context.Background(), &user, input.Code, input.RecoveryCode)
+	if err != nil {
+		log.Printf("Error checking second factor for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		log.Printf("MFA verification failed for user %s", user.Email)
+		recordLoginFailure(c, accountKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	// The challenge can only be exchanged once
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge. Please log in again."})
+			return
+		}
+		log.Printf("Error consuming MFA challenge for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+
+	tokens, err := startSession(c, &user)
+	if err != nil {
+		log.Printf("Error issuing tokens for user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate login token"})
+		return
+	}
+
+	resetLoginFailures(accountKey)
+	log.Printf("User logged in successfully with 2FA: %s", user.Email)
+	tokens["user"] = newUserResponse(&user)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// checkSecondFactor verifies a TOTP code or burns a recovery code. TOTP codes
+// are accepted at most once per time step; each recovery code works once.
+func checkSecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) (bool, error) {
+	userCollection := database.GetCollection("users")
+
+	if recoveryCode != "" {
+		hash := auth.HashRecoveryCode(recoveryCode)
+		result, err := userCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "recovery_codes": hash},
+			bson.M{"$pull": bson.M{"recovery_codes": hash}},
+		)
+		if err != nil {
+			return false, err
+		}
+		if result.ModifiedCount > 0 {
+			log.Printf("Recovery code used by user %s (%d remaining)", user.ID.Hex(), len(user.RecoveryCodeHashes)-1)
+		}
+		return result.ModifiedCount > 0, nil
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	result, err := userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "totp_last_used_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"totp_last_used_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
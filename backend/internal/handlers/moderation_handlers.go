@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	reportOpen      = "open"
+	reportResolved  = "resolved"
+	reportDismissed = "dismissed"
+)
+
+// BlockUserHandler blocks another user. From then on neither of them sees the
+// other in the peer directory, can schedule an interview with the other or
+// join a room with them; open connections in rooms they share are closed.
+// Blocking someone already blocked is a no-op.
+func BlockUserHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	targetID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	var target models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": targetID}).Decode(&target); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error finding user %s to block: %v", targetID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_, err = database.GetCollection("user_blocks").UpdateOne(context.Background(),
+		bson.M{"blocker_id": userID, "blocked_id": targetID},
+		bson.M{"$setOnInsert": bson.M{"createdAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Printf("Error storing block of %s by %s: %v", targetID.Hex(), userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	disconnectSharedRooms(context.Background(), userID, targetID)
+
+	log.Printf("User %s blocked user %s", userID.Hex(), targetID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+// UnblockUserHandler lifts a block the current user placed. Blocks placed by
+// the other user stay in effect.
+func UnblockUserHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	targetID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	result, err := database.GetCollection("user_blocks").DeleteOne(context.Background(), bson.M{"blocker_id": userID, "blocked_id": targetID})
+	if err != nil {
+		log.Printf("Error removing block of %s by %s: %v", targetID.Hex(), userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You have not blocked this user"})
+		return
+	}
+
+	log.Printf("User %s unblocked user %s", userID.Hex(), targetID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// ListBlockedUsersHandler lists the users the current user blocked, newest first.
+func ListBlockedUsersHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	cursor, err := database.GetCollection("user_blocks").Find(context.Background(),
+		bson.M{"blocker_id": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		log.Printf("Error listing blocks of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users"})
+		return
+	}
+	var blocks []models.UserBlock
+	if err := cursor.All(context.Background(), &blocks); err != nil {
+		log.Printf("Error decoding blocks of user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users"})
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(blocks))
+	for _, block := range blocks {
+		ids = append(ids, block.BlockedID)
+	}
+	names := make(map[primitive.ObjectID]string)
+	if len(ids) > 0 {
+		var users []models.UserInfo
+		userCursor, err := database.GetCollection("users").Find(context.Background(),
+			bson.M{"_id": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"name": 1}),
+		)
+		if err == nil {
+			err = userCursor.All(context.Background(), &users)
+		}
+		if err != nil {
+			log.Printf("Error loading names of users blocked by %s: %v", userID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users"})
+			return
+		}
+		for _, u := range users {
+			names[u.ID] = u.Name
+		}
+	}
+
+	response := make([]models.BlockedUserResponse, 0, len(blocks))
+	for _, block := range blocks {
+		name, ok := names[block.BlockedID]
+		if !ok {
+			name = deletedUserName
+		}
+		response = append(response, models.BlockedUserResponse{ID: block.BlockedID, Name: name, BlockedAt: block.CreatedAt})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ReportUserHandler files a report about another user for the admin
+// moderation queue. It can point at an interview both users took part in and
+// quote a chat message from it.
+func ReportUserHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	targetID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report yourself"})
+		return
+	}
+	var input models.ReportUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	if input.Message != nil && input.InterviewID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quoting a chat message requires the interview_id it was sent in"})
+		return
+	}
+
+	userCollection := database.GetCollection("users")
+	var reporter, reported models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&reporter); err != nil {
+		log.Printf("Error finding reporting user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+		return
+	}
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": targetID}).Decode(&reported); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error finding reported user %s: %v", targetID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+		return
+	}
+
+	report := models.UserReport{
+		ID:           primitive.NewObjectID(),
+		ReporterID:   reporter.ID,
+		ReporterName: reporter.Name,
+		ReportedID:   reported.ID,
+		ReportedName: reported.Name,
+		Reason:       input.Reason,
+		Details:      input.Details,
+		Message:      input.Message,
+		Status:       reportOpen,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if input.InterviewID != "" {
+		interviewID, _ := primitive.ObjectIDFromHex(input.InterviewID) // Checked by binding
+		count, err := database.GetCollection("interviews").CountDocuments(context.Background(), bson.M{
+			"_id": interviewID,
+			"$or": []bson.M{
+				{"interviewer_id": userID, "interviewee_id": targetID},
+				{"interviewer_id": targetID, "interviewee_id": userID},
+			},
+		})
+		if err != nil {
+			log.Printf("Error checking interview %s for report by %s: %v", interviewID.Hex(), userID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The interview must be one you had with this user"})
+			return
+		}
+		report.InterviewID = &interviewID
+	}
+
+	if _, err := database.GetCollection("user_reports").InsertOne(context.Background(), report); err != nil {
+		log.Printf("Error storing report about %s by %s: %v", targetID.Hex(), userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+		return
+	}
+
+	log.Printf("User %s reported user %s for %s (report %s)", userID.Hex(), targetID.Hex(), input.Reason, report.ID.Hex())
+	c.JSON(http.StatusCreated, gin.H{"id": report.ID, "status": report.Status, "message": "Thanks, our moderators will review your report."})
+}
+
+// ListReportsHandler is the admin moderation queue, oldest first so reports
+// are handled in order. It shows open reports unless ?status= asks for
+// resolved, dismissed or all, and ?user_id= narrows it to reports about one user.
+func ListReportsHandler(c *gin.Context) {
+	filter := bson.M{}
+	switch status := c.DefaultQuery("status", reportOpen); status {
+	case reportOpen, reportResolved, reportDismissed:
+		filter["status"] = status
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be open, resolved, dismissed or all"})
+		return
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		reportedID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+			return
+		}
+		filter["reported_id"] = reportedID
+	}
+
+	cursor, err := database.GetCollection("user_reports").Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(200),
+	)
+	if err != nil {
+		log.Printf("Error listing reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+	reports := []models.UserReport{}
+	if err := cursor.All(context.Background(), &reports); err != nil {
+		log.Printf("Error decoding reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReportHandler closes a report after action was taken (e.g. a ban,
+// which is a separate admin action).
+func ResolveReportHandler(c *gin.Context) {
+	closeReportHandler(c, reportResolved)
+}
+
+// DismissReportHandler closes a report that needs no action.
+func DismissReportHandler(c *gin.Context) {
+	closeReportHandler(c, reportDismissed)
+}
+
+func closeReportHandler(c *gin.Context, status string) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.ResolveReportInput
+	if !bindOptionalJSON(c, &input) {
+		return
+	}
+	reportID, err := primitive.ObjectIDFromHex(c.Param("reportId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID format"})
+		return
+	}
+
+	set := bson.M{"status": status, "resolved_by": adminID, "resolved_at": time.Now().UTC()}
+	if input.Note != "" {
+		set["resolution_note"] = input.Note
+	}
+	// Only open reports can be closed, so two admins can't both handle one
+	var report models.UserReport
+	err = database.GetCollection("user_reports").FindOneAndUpdate(context.Background(),
+		bson.M{"_id": reportID, "status": reportOpen},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No open report with this ID"})
+			return
+		}
+		log.Printf("Error closing report %s: %v", reportID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report"})
+		return
+	}
+
+	action := "report.resolve"
+	if status == reportDismissed {
+		action = "report.dismiss"
+	}
+	recordAudit(c, action, "report", reportID.Hex(), map[string]interface{}{
+		"reported_id": report.ReportedID.Hex(),
+		"note":        input.Note,
+	})
+	log.Printf("Admin %s %s report %s", adminID.Hex(), status, reportID.Hex())
+	c.JSON(http.StatusOK, report)
+}
+
+// isBlocked reports whether either user has blocked the other.
+func isBlocked(ctx context.Context, a, b primitive.ObjectID) (bool, error) {
+	count, err := database.GetCollection("user_blocks").CountDocuments(ctx, bson.M{"$or": []bson.M{
+		{"blocker_id": a, "blocked_id": b},
+		{"blocker_id": b, "blocked_id": a},
+	}}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// blockedUserIDs returns the users the given user blocked or was blocked by.
+func blockedUserIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := database.GetCollection("user_blocks").Find(ctx, bson.M{"$or": []bson.M{
+		{"blocker_id": userID},
+		{"blocked_id": userID},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	var blocks []models.UserBlock
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(blocks))
+	for _, block := range blocks {
+		if block.BlockerID == userID {
+			ids = append(ids, block.BlockedID)
+		} else {
+			ids = append(ids, block.BlockerID)
+		}
+	}
+	return ids, nil
+}
+
+// disconnectSharedRooms closes both users' live connections to interview
+// rooms they share.
+func disconnectSharedRooms(ctx context.Context, a, b primitive.ObjectID) {
+	cursor, err := database.GetCollection("interviews").Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"interviewer_id": a, "interviewee_id": b},
+			{"interviewer_id": b, "interviewee_id": a},
+		},
+		"status": bson.M{"$in": []string{"scheduled", "in_progress"}},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Printf("Error finding interviews shared by %s and %s: %v", a.Hex(), b.Hex(), err)
+		return
+	}
+	var interviews []models.Interview
+	if err := cursor.All(ctx, &interviews); err != nil {
+		log.Printf("Error decoding interviews shared by %s and %s: %v", a.Hex(), b.Hex(), err)
+		return
+	}
+	rooms := make(map[string]bool)
+	for _, interview := range interviews {
+		rooms[interview.ID.Hex()] = true
+	}
+	if len(rooms) == 0 {
+		return
+	}
+	hub.DisconnectWhere(func(cl *Client) bool {
+		return rooms[cl.InterviewID] && (cl.UserID == a.Hex() || cl.UserID == b.Hex())
+	}, "Participant blocked")
+}
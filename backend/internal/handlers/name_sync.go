@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// A claimed job is left alone by other workers for this long, so a
+	// worker that dies mid-job only delays it
+	nameSyncLease = 2 * time.Minute
+	// Failed jobs are retried after 30s, doubling up to this
+	nameSyncMaxBackoff = time.Hour
+)
+
+// nameSyncKick wakes the worker early, e.g. right after a rename.
+var nameSyncKick = make(chan struct{}, 1)
+
+// errNameSyncCancelled means the job stopped being wanted while it ran: the
+// user renamed again, or their account is being deleted.
+var errNameSyncCancelled = errors.New("name sync: job replaced or user deleted")
+
+// StartNameSync runs the worker that copies renamed users' names into their
+// interviews until ctx is cancelled. Pending jobs are stored on the users
+// (see models.NameSyncJob), so nothing is lost if this process stops.
+func StartNameSync(ctx context.Context) {
+	ticker := time.NewTicker(config.AppConfig.NameSyncInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			runNameSync(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-nameSyncKick:
+			}
+		}
+	}()
+}
+
+// kickNameSync asks the worker to look for due jobs now.
+func kickNameSync() {
+	select {
+	case nameSyncKick <- struct{}{}:
+	default: // A run is already pending
+	}
+}
+
+// newNameSyncJob is the job stored together with a rename.
+func newNameSyncJob(name string, now time.Time) models.NameSyncJob {
+	return models.NameSyncJob{Name: name, RequestedAt: now, NextAttemptAt: now}
+}
+
+// runNameSync processes due jobs one at a time until none are left. Users
+// scheduled for deletion are skipped, since their purge anonymizes the same
+// interviews.
+func runNameSync(ctx context.Context) {
+	users := database.GetCollection("users")
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		var user models.User
+		err := users.FindOneAndUpdate(ctx,
+			bson.M{"name_sync.next_attempt_at": bson.M{"$lte": now}, "deletion_scheduled_for": nil},
+			bson.M{"$set": bson.M{
+				"name_sync.next_attempt_at":  now.Add(nameSyncLease),
+				"name_sync.lease_expires_at": now.Add(nameSyncLease),
+			}},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "name_sync.next_attempt_at", Value: 1}}).
+				SetProjection(bson.M{"name_sync": 1}).
+				SetReturnDocument(options.After),
+		).Decode(&user)
+		if err != nil {
+			if err != mongo.ErrNoDocuments && ctx.Err() == nil {
+				log.Printf("Error claiming name sync job: %v", err)
+			}
+			return
+		}
+		job := user.NameSync
+
+		policy := job.Policy
+		if policy == "" {
+			policy = config.AppConfig.NamePropagationPolicy
+		}
+		// Only the job that was claimed is cleared or rescheduled; a rename
+		// made in the meantime replaced it and still has to run. A purge
+		// removes the job, which also stops this run before its next write.
+		current := bson.M{"_id": user.ID, "name_sync.requested_at": job.RequestedAt, "deletion_scheduled_for": nil}
+		if err := syncInterviewNames(ctx, user.ID, job.Name, policy, current); err != nil {
+			if err == errNameSyncCancelled {
+				log.Printf("Name sync of user %s stopped: renamed again or deleted", user.ID.Hex())
+				continue
+			}
+			backoff := min(30*time.Second<<min(job.Attempts, 16), nameSyncMaxBackoff)
+			log.Printf("Error syncing name of user %s into interviews (attempt %d, retrying in %s): %v", user.ID.Hex(), job.Attempts+1, backoff, err)
+			_, err = users.UpdateOne(ctx, current, bson.M{
+				"$set":   bson.M{"name_sync.next_attempt_at": now.Add(backoff), "name_sync.last_error": err.Error()},
+				"$unset": bson.M{"name_sync.lease_expires_at": ""},
+				"$inc":   bson.M{"name_sync.attempts": 1},
+			})
+			if err != nil {
+				log.Printf("Error rescheduling name sync of user %s: %v", user.ID.Hex(), err)
+			}
+			continue
+		}
+		if _, err := users.UpdateOne(ctx, current, bson.M{"$unset": bson.M{"name_sync": ""}}); err != nil {
+			// The lease runs out and the job is repeated, which is harmless
+			log.Printf("Error completing name sync of user %s: %v", user.ID.Hex(), err)
+		}
+	}
+}
+
+// syncInterviewNames sets the user's name as interviewer and interviewee
+// name on the interviews the policy covers. Before each write it checks that
+// the user still matches job, and returns errNameSyncCancelled if not.
+func syncInterviewNames(ctx context.Context, userID primitive.ObjectID, name, policy string, job bson.M) error {
+	now := time.Now().UTC()
+	for _, side := range []struct{ idField, nameField string }{
+		{"interviewer_id", "interviewer_name"},
+		{"interviewee_id", "interviewee_name"},
+	} {
+		wanted, err := database.GetCollection("users").CountDocuments(ctx, job, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if wanted == 0 {
+			return errNameSyncCancelled
+		}
+		filter := bson.M{side.idField: userID, side.nameField: bson.M{"$ne": name}}
+		switch policy {
+		case "future":
+			filter["scheduled_time"] = bson.M{"$gt": now}
+		case "unfinished":
+			filter["status"] = bson.M{"$nin": []string{"completed", "cancelled", "no_show"}}
+		}
+		if _, err := database.GetCollection("interviews").UpdateMany(ctx, filter,
+			bson.M{"$set": bson.M{side.nameField: name, "updatedAt": now}},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdminReconcileNamesHandler queues a re-sync of every user's name into their
+// interviews, under the policy given in the body or NAME_PROPAGATION_POLICY.
+// The work is done by the name sync worker; the response says how many users
+// were queued.
+func AdminReconcileNamesHandler(c *gin.Context) {
+	var input models.ReconcileNamesInput
+	if !bindOptionalJSON(c, &input) {
+		return
+	}
+	policy := input.Policy
+	if policy == "" {
+		policy = config.AppConfig.NamePropagationPolicy
+	}
+
+	// A pipeline update copies each user's current name into their job
+	now := time.Now().UTC()
+	result, err := database.GetCollection("users").UpdateMany(context.Background(), bson.M{}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{{Key: "name_sync", Value: bson.D{
+			{Key: "name", Value: "$name"},
+			{Key: "policy", Value: policy},
+			{Key: "requested_at", Value: now},
+			{Key: "next_attempt_at", Value: now},
+		}}}}},
+	})
+	if err != nil {
+		log.Printf("Error queueing name reconcile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start name reconcile"})
+		return
+	}
+	kickNameSync()
+
+	recordAudit(c, "interview.names.reconcile", "interview", "", map[string]interface{}{
+		"policy": policy,
+		"users":  result.ModifiedCount,
+	})
+	log.Printf("Queued name reconcile for %d users with policy %s", result.ModifiedCount, policy)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Names are being re-synced in the background",
+		"policy":  policy,
+		"queued":  result.ModifiedCount,
+	})
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+	"mock-orbit/backend/internal/oidc"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	oidcStateTTL     = 10 * time.Minute
+	oidcLoginCodeTTL = 2 * time.Minute
+)
+
+// errOIDCEmailInUse means the provider's email belongs to an existing account
+// but the provider has not verified it, so the accounts cannot be linked.
+var errOIDCEmailInUse = errors.New("email already registered")
+
+// errOIDCRoleRequired means a new account would be created but no role was chosen.
+var errOIDCRoleRequired = errors.New("role required for new account")
+
+// ListOIDCProvidersHandler lists the configured social login providers.
+func ListOIDCProvidersHandler(c *gin.Context) {
+	providers := []gin.H{}
+	for _, p := range config.AppConfig.OIDCProviders {
+		providers = append(providers, gin.H{
+			"name":         p.Name,
+			"display_name": p.DisplayName,
+			"login_url":    "/api/v1/auth/oidc/" + p.Name + "/login",
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i]["name"].(string) < providers[j]["name"].(string)
+	})
+	c.JSON(http.StatusOK, providers)
+}
+
+// OIDCLoginHandler starts the authorization-code flow with PKCE. The optional
+// role query parameter is the role chosen during onboarding; it is only used
+// if the login creates a new account.
+func OIDCLoginHandler(c *gin.Context) {
+	providerName := c.Param("provider")
+	provider, err := oidc.GetProvider(providerName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	role := c.Query("role")
+	if role != "" && role != "interviewer" && role != "interviewee" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be interviewer or interviewee"})
+		return
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		log.Printf("Error generating OIDC state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		log.Printf("Error generating OIDC nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	verifier, challenge, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		log.Printf("Error generating PKCE verifier: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, challenge)
+	if err != nil {
+		log.Printf("Error building authorization URL for provider %s: %v", providerName, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider is unavailable"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_, err = database.GetCollection("oidc_states").InsertOne(context.Background(), models.OIDCLoginState{
+		State:        state,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		Role:         role,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		log.Printf("Error storing OIDC state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallbackHandler handles the provider's redirect. It finds, links or
+// creates the local account, then redirects to the frontend with a one-time
+// code that POST /auth/oidc/exchange trades for a session.
+func OIDCCallbackHandler(c *gin.Context) {
+	providerName := c.Param("provider")
+	provider, err := oidc.GetProvider(providerName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		log.Printf("OIDC provider %s returned error: %s (%s)", providerName, errParam, c.Query("error_description"))
+		redirectOIDCError(c, "provider_error")
+		return
+	}
+
+	var state models.OIDCLoginState
+	err = database.GetCollection("oidc_states").FindOneAndDelete(context.Background(), bson.M{
+		"_id":        c.Query("state"),
+		"provider":   providerName,
+		"expires_at": bson.M{"$gt": time.Now().UTC()},
+	}).Decode(&state)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error loading OIDC state: %v", err)
+		}
+		redirectOIDCError(c, "invalid_state")
+		return
+	}
+
+	tokens, err := provider.Exchange(context.Background(), c.Query("code"), state.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", providerName, err)
+		redirectOIDCError(c, "exchange_failed")
+		return
+	}
+	claims, err := provider.VerifyIDToken(context.Background(), tokens.IDToken, state.Nonce)
+	if err != nil {
+		log.Printf("OIDC id token from %s rejected: %v", providerName, err)
+		redirectOIDCError(c, "invalid_id_token")
+		return
+	}
+
+	user, err := findOrCreateOIDCUser(context.Background(), providerName, claims, state.Role)
+	if err != nil {
+		switch err {
+		case errOIDCEmailInUse:
+			redirectOIDCError(c, "email_in_use")
+		case errOIDCRoleRequired:
+			redirectOIDCError(c, "role_required")
+		default:
+			log.Printf("Error resolving account for %s subject %s: %v", providerName, claims.Subject, err)
+			redirectOIDCError(c, "server_error")
+		}
+		return
+	}
+
+	loginCode, err := auth.NewActionToken(auth.PurposeOIDCLogin, user.ID, oidcLoginCodeTTL, nil)
+	if err != nil {
+		log.Printf("Error issuing OIDC login code for user %s: %v", user.ID.Hex(), err)
+		redirectOIDCError(c, "server_error")
+		return
+	}
+
+	log.Printf("OIDC login via %s succeeded for user %s", providerName, user.Email)
+	c.Redirect(http.StatusFound, frontendLink("/auth/oidc/callback?code="+url.QueryEscape(loginCode)))
+}
+
+// OIDCExchangeHandler trades the one-time code from the callback redirect for
+// a session, exactly as if the user had logged in with a password.
+func OIDCExchangeHandler(c *gin.Context) {
+	var input models.OIDCExchangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	claims, err := auth.ParseActionToken(auth.PurposeOIDCLogin, input.Code)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+	userID, err := auth.ClaimObjectID(claims, "user_id")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+			return
+		}
+		log.Printf("Error consuming OIDC login code for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+		return
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("User %s for OIDC login code not found: %v", userID.Hex(), err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+
+	completeLogin(c, &user)
+}
+
+// findOrCreateOIDCUser resolves the local account for an external identity:
+// an already linked account, else an existing account with the same verified
+// email (which gets linked), else a new account with the onboarding role.
+// Linking an account whose email was never verified clears its password and
+// 2FA and logs it out everywhere, so a pre-registered account can't be used
+// to get into the real owner's.
+func findOrCreateOIDCUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims, role string) (*models.User, error) {
+	userCollection := database.GetCollection("users")
+	now := time.Now().UTC()
+
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": providerName, "subject": claims.Subject}},
+	}).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	identity := models.ExternalIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: now,
+	}
+	email := strings.TrimSpace(claims.Email)
+
+	if email != "" {
+		err = userCollection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+		if err == nil {
+			// Only link when the provider vouches for the address; otherwise anyone
+			// could claim an account by registering the email at some IdP.
+			if !claims.EmailVerified {
+				return nil, errOIDCEmailInUse
+			}
+			filter := bson.M{"_id": user.ID}
+			update := bson.M{
+				"$push": bson.M{"identities": identity},
+				"$set":  bson.M{"updatedAt": now},
+			}
+			if user.EmailVerificationPending {
+				// Whoever registered the unverified account may not own the
+				// address. The provider has now proven who does, so nothing the
+				// registrant set up to get back in survives the link.
+				filter["email_verification_pending"] = true
+				update["$set"] = bson.M{"updatedAt": now, "email_verified_at": now}
+				update["$unset"] = bson.M{
+					"email_verification_pending": "",
+					"password":                   "",
+					"totp_enabled":               "",
+					"totp_secret":                "",
+					"totp_pending_secret":        "",
+					"totp_last_used_step":        "",
+					"recovery_codes":             "",
+					"pending_email":              "",
+					"pending_email_requested_at": "",
+				}
+			}
+			result, err := userCollection.UpdateOne(ctx, filter, update)
+			if err != nil {
+				return nil, err
+			}
+			if result.MatchedCount == 0 {
+				// Verified in the meantime; link it as a verified account
+				return findOrCreateOIDCUser(ctx, providerName, claims, role)
+			}
+			log.Printf("Linked %s identity %s to existing user %s", providerName, claims.Subject, user.Email)
+			if user.EmailVerificationPending {
+				if err := revokeAllSessions(ctx, user.ID); err != nil {
+					return nil, err
+				}
+				log.Printf("Cleared credentials and revoked sessions of unverified user %s on linking", user.Email)
+				user.TokenVersion++
+				user.Password = ""
+				user.TOTPEnabled = false
+				user.TOTPSecret = ""
+				user.RecoveryCodeHashes = nil
+				claimGuestHistory(ctx, &user)
+			}
+			user.Identities = append(user.Identities, identity)
+			user.EmailVerificationPending = false
+			return &user, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	if role == "" {
+		return nil, errOIDCRoleRequired
+	}
+	if email == "" {
+		return nil, errors.New("provider did not return an email address")
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if len(name) < 2 {
+		name = strings.Split(email, "@")[0]
+	}
+	user = models.User{
+		ID:                       primitive.NewObjectID(),
+		Name:                     name,
+		Email:                    email,
+		Role:                     role,
+		AvailableRoles:           []string{role},
+		SearchKeys:               userSearchKeys(name),
+		EmailVerificationPending: !claims.EmailVerified,
+		Identities:               []models.ExternalIdentity{identity},
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	if claims.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+	if claims.Picture != "" {
+		picture := claims.Picture
+		user.ProfilePictureURL = &picture
+	}
+	if _, err := userCollection.InsertOne(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if user.EmailVerificationPending {
+		if err := sendVerificationEmail(ctx, &user); err != nil {
+			log.Printf("Error sending verification email to %s: %v", user.Email, err)
+		}
+	} else {
+		claimGuestHistory(ctx, &user)
+	}
+	log.Printf("Created user %s from %s identity %s, Role: %s", user.Email, providerName, claims.Subject, role)
+	return &user, nil
+}
+
+// redirectOIDCError sends the browser back to the frontend with an error code.
+func redirectOIDCError(c *gin.Context, code string) {
+	c.Redirect(http.StatusFound, frontendLink("/auth/oidc/callback?error="+url.QueryEscape(code)))
+}
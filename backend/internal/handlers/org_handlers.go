@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	orgRoleOwner  = "owner"
+	orgRoleAdmin  = "admin"
+	orgRoleMember = "member"
+
+	// maxOrgMemberships caps how many organizations one user can belong to.
+	maxOrgMemberships = 50
+)
+
+// orgRoleRank orders organization roles so checks can ask for "at least admin".
+var orgRoleRank = map[string]int{orgRoleMember: 0, orgRoleAdmin: 1, orgRoleOwner: 2}
+
+// tenantID returns the organization the request acts in, or nil for the
+// public space. Every query for tenant data in this package filters on it.
+func tenantID(c *gin.Context) *primitive.ObjectID {
+	orgID, _ := c.Get("orgID")
+	id, _ := orgID.(*primitive.ObjectID)
+	return id
+}
+
+// tenantUserFilter matches the users visible in a tenant: the members of the
+// organization, or users who belong to no organization in the public space.
+func tenantUserFilter(orgID *primitive.ObjectID) bson.M {
+	if orgID == nil {
+		return bson.M{"memberships.0": bson.M{"$exists": false}}
+	}
+	return bson.M{"memberships.org_id": *orgID}
+}
+
+// inTenant is tenantUserFilter for a user that is already loaded.
+func inTenant(user *models.User, orgID *primitive.ObjectID) bool {
+	if orgID == nil {
+		return len(user.Memberships) == 0
+	}
+	return orgMembership(user, *orgID) != nil
+}
+
+// orgMembership returns the user's membership in the organization, or nil.
+func orgMembership(user *models.User, orgID primitive.ObjectID) *models.OrgMembership {
+	for i := range user.Memberships {
+		if user.Memberships[i].OrgID == orgID {
+			return &user.Memberships[i]
+		}
+	}
+	return nil
+}
+
+// requireOrgRole loads the caller and the organization in the orgId path
+// parameter and checks the caller holds at least minRole in it. It writes the
+// error response and returns false otherwise. Non-members get a 404 so they
+// can't probe for organizations.
+func requireOrgRole(c *gin.Context, minRole string) (*models.User, *models.Organization, bool) {
+	callerID := c.MustGet("userObjectID").(primitive.ObjectID)
+	orgID, err := primitive.ObjectIDFromHex(c.Param("orgId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID format"})
+		return nil, nil, false
+	}
+
+	var caller models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": callerID}).Decode(&caller); err != nil {
+		log.Printf("Error finding user %s for organization %s: %v", callerID.Hex(), orgID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load organization"})
+		return nil, nil, false
+	}
+	membership := orgMembership(&caller, orgID)
+	if membership == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return nil, nil, false
+	}
+	if orgRoleRank[membership.Role] < orgRoleRank[minRole] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This requires the " + minRole + " role in the organization", "org_role": membership.Role})
+		return nil, nil, false
+	}
+
+	var org models.Organization
+	if err := database.GetCollection("organizations").FindOne(context.Background(), bson.M{"_id": orgID}).Decode(&org); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return nil, nil, false
+		}
+		log.Printf("Error finding organization %s: %v", orgID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load organization"})
+		return nil, nil, false
+	}
+	return &caller, &org, true
+}
+
+// countOrgOwners returns how many owners the organization has.
+func countOrgOwners(ctx context.Context, orgID primitive.ObjectID) (int64, error) {
+	return database.GetCollection("users").CountDocuments(ctx, bson.M{
+		"memberships": bson.M{"$elemMatch": bson.M{"org_id": orgID, "role": orgRoleOwner}},
+	})
+}
+
+// ListMyOrganizationsHandler lists the organizations the current user belongs to.
+func ListMyOrganizationsHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s to list organizations: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve organizations"})
+		return
+	}
+	response := []models.OrganizationResponse{}
+	if len(user.Memberships) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	orgIDs := make([]primitive.ObjectID, 0, len(user.Memberships))
+	for _, membership := range user.Memberships {
+		orgIDs = append(orgIDs, membership.OrgID)
+	}
+	cursor, err := database.GetCollection("organizations").Find(context.Background(),
+		bson.M{"_id": bson.M{"$in": orgIDs}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		log.Printf("Error listing organizations for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve organizations"})
+		return
+	}
+	var orgs []models.Organization
+	if err := cursor.All(context.Background(), &orgs); err != nil {
+		log.Printf("Error decoding organizations for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve organizations"})
+		return
+	}
+	for _, org := range orgs {
+		response = append(response, models.OrganizationResponse{
+			Organization: org,
+			Role:         orgMembership(&user, org.ID).Role,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateOrganizationHandler creates an organization with the caller as its
+// owner. The caller switches to it with POST /users/active-org.
+func CreateOrganizationHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.CreateOrganizationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	org := models.Organization{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(input.Name),
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := database.GetCollection("organizations").InsertOne(context.Background(), org); err != nil {
+		log.Printf("Error creating organization for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		return
+	}
+
+	membership := models.OrgMembership{OrgID: org.ID, Role: orgRoleOwner, JoinedAt: now}
+	result, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": userID, fmt.Sprintf("memberships.%d", maxOrgMemberships-1): bson.M{"$exists": false}},
+		bson.M{"$push": bson.M{"memberships": membership}, "$set": bson.M{"updatedAt": now}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		// Don't leave an organization nobody can manage
+		if _, delErr := database.GetCollection("organizations").DeleteOne(context.Background(), bson.M{"_id": org.ID}); delErr != nil {
+			log.Printf("Error removing ownerless organization %s: %v", org.ID.Hex(), delErr)
+		}
+		if err != nil {
+			log.Printf("Error adding user %s as owner of organization %s: %v", userID.Hex(), org.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can belong to at most %d organizations", maxOrgMemberships)})
+		return
+	}
+
+	log.Printf("User %s created organization %s (%s)", userID.Hex(), org.ID.Hex(), org.Name)
+	c.JSON(http.StatusCreated, models.OrganizationResponse{Organization: org, Role: orgRoleOwner})
+}
+
+// GetOrganizationHandler returns an organization the caller belongs to.
+func GetOrganizationHandler(c *gin.Context) {
+	caller, org, ok := requireOrgRole(c, orgRoleMember)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, models.OrganizationResponse{
+		Organization: *org,
+		Role:         orgMembership(caller, org.ID).Role,
+	})
+}
+
+// UpdateOrganizationHandler renames an organization or replaces its topic
+// list. Org admins and owners only.
+func UpdateOrganizationHandler(c *gin.Context) {
+	caller, org, ok := requireOrgRole(c, orgRoleAdmin)
+	if !ok {
+		return
+	}
+	var input models.UpdateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	if input.Name != nil {
+		set["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Topics != nil {
+		if len(*input.Topics) == 0 {
+			unset["topics"] = ""
+		} else {
+			seen := make(map[string]bool)
+			for _, topic := range *input.Topics {
+				if seen[topic.ID] {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate topic ID: " + topic.ID})
+					return
+				}
+				seen[topic.ID] = true
+			}
+			set["topics"] = *input.Topics
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No update fields provided"})
+		return
+	}
+	set["updatedAt"] = time.Now().UTC()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var updated models.Organization
+	err := database.GetCollection("organizations").FindOneAndUpdate(context.Background(),
+		bson.M{"_id": org.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		log.Printf("Error updating organization %s: %v", org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
+		return
+	}
+
+	log.Printf("User %s updated organization %s", caller.Email, org.ID.Hex())
+	c.JSON(http.StatusOK, models.OrganizationResponse{
+		Organization: updated,
+		Role:         orgMembership(caller, org.ID).Role,
+	})
+}
+
+// ListOrgMembersHandler lists the members of an organization the caller belongs to.
+func ListOrgMembersHandler(c *gin.Context) {
+	_, org, ok := requireOrgRole(c, orgRoleMember)
+	if !ok {
+		return
+	}
+
+	cursor, err := database.GetCollection("users").Find(context.Background(),
+		bson.M{"memberships.org_id": org.ID},
+		options.Find().
+			SetProjection(bson.M{"name": 1, "email": 1, "memberships": 1}).
+			SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		log.Printf("Error listing members of organization %s: %v", org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+	var users []models.User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		log.Printf("Error decoding members of organization %s: %v", org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+
+	response := make([]models.OrgMemberResponse, 0, len(users))
+	for i := range users {
+		membership := orgMembership(&users[i], org.ID)
+		response = append(response, models.OrgMemberResponse{
+			ID:       users[i].ID,
+			Name:     users[i].Name,
+			Email:    users[i].Email,
+			Role:     membership.Role,
+			JoinedAt: membership.JoinedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AddOrgMemberHandler adds an existing user to an organization by email and
+// lets them know. Org admins can add members and admins; only owners can add
+// owners.
+func AddOrgMemberHandler(c *gin.Context) {
+	caller, org, ok := requireOrgRole(c, orgRoleAdmin)
+	if !ok {
+		return
+	}
+	var input models.AddOrgMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	if input.Role == "" {
+		input.Role = orgRoleMember
+	}
+	if orgRoleRank[input.Role] > orgRoleRank[orgMembership(caller, org.ID).Role] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can add owners"})
+		return
+	}
+
+	var user models.User
+	err := database.GetCollection("users").FindOne(context.Background(), bson.M{
+		"email":  strings.ToLower(strings.TrimSpace(input.Email)),
+		"banned": bson.M{"$ne": true},
+	}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No user with this email. They need to register first."})
+			return
+		}
+		log.Printf("Error finding user to add to organization %s: %v", org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	if orgMembership(&user, org.ID) != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This user is already a member"})
+		return
+	}
+
+	now := time.Now().UTC()
+	membership := models.OrgMembership{OrgID: org.ID, Role: input.Role, JoinedAt: now}
+	result, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{
+			"_id":                user.ID,
+			"memberships.org_id": bson.M{"$ne": org.ID},
+			fmt.Sprintf("memberships.%d", maxOrgMemberships-1): bson.M{"$exists": false},
+		},
+		bson.M{"$push": bson.M{"memberships": membership}, "$set": bson.M{"updatedAt": now}},
+	)
+	if err != nil {
+		log.Printf("Error adding user %s to organization %s: %v", user.Email, org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This user is already a member or belongs to too many organizations"})
+		return
+	}
+
+	err = mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "You were added to " + org.Name + " on Mock Orbit",
+		Body: fmt.Sprintf("Hi %s,\n\n%s added you to %s on Mock Orbit as a %s. Switch to it from the organization menu to see its members and interviews:\n\n%s\n",
+			user.Name, caller.Name, org.Name, input.Role, frontendLink("/dashboard")),
+	})
+	if err != nil {
+		log.Printf("Error sending organization notice to %s: %v", user.Email, err)
+	}
+
+	log.Printf("User %s added %s to organization %s as %s", caller.Email, user.Email, org.ID.Hex(), input.Role)
+	c.JSON(http.StatusCreated, models.OrgMemberResponse{
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     input.Role,
+		JoinedAt: now,
+	})
+}
+
+// UpdateOrgMemberHandler changes a member's role. Admins manage members and
+// admins; only owners can touch owners, and the last owner can't be demoted.
+func UpdateOrgMemberHandler(c *gin.Context) {
+	caller, org, ok := requireOrgRole(c, orgRoleAdmin)
+	if !ok {
+		return
+	}
+	var input models.UpdateOrgMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	member, ok := findOrgMember(c, org.ID)
+	if !ok {
+		return
+	}
+
+	callerRole := orgMembership(caller, org.ID).Role
+	current := orgMembership(member, org.ID).Role
+	if callerRole != orgRoleOwner && (current == orgRoleOwner || input.Role == orgRoleOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can change owners"})
+		return
+	}
+	if current == input.Role {
+		c.JSON(http.StatusOK, newOrgMemberResponse(member, org.ID))
+		return
+	}
+	if current == orgRoleOwner {
+		owners, err := countOrgOwners(context.Background(), org.ID)
+		if err != nil {
+			log.Printf("Error counting owners of organization %s: %v", org.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+			return
+		}
+		if owners <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "An organization needs at least one owner. Make someone else an owner first."})
+			return
+		}
+	}
+
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": member.ID, "memberships.org_id": org.ID},
+		bson.M{"$set": bson.M{"memberships.$.role": input.Role, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error updating role of %s in organization %s: %v", member.Email, org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	orgMembership(member, org.ID).Role = input.Role
+
+	log.Printf("User %s changed role of %s in organization %s from %s to %s", caller.Email, member.Email, org.ID.Hex(), current, input.Role)
+	c.JSON(http.StatusOK, newOrgMemberResponse(member, org.ID))
+}
+
+// RemoveOrgMemberHandler removes a member from an organization. Members can
+// remove themselves to leave; removing anyone else takes an admin, or an
+// owner if the member is an owner. The last owner can't leave.
+func RemoveOrgMemberHandler(c *gin.Context) {
+	caller, org, ok := requireOrgRole(c, orgRoleMember)
+	if !ok {
+		return
+	}
+	member, ok := findOrgMember(c, org.ID)
+	if !ok {
+		return
+	}
+
+	callerRole := orgMembership(caller, org.ID).Role
+	memberRole := orgMembership(member, org.ID).Role
+	if member.ID != caller.ID {
+		if orgRoleRank[callerRole] < orgRoleRank[orgRoleAdmin] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This requires the admin role in the organization", "org_role": callerRole})
+			return
+		}
+		if memberRole == orgRoleOwner && callerRole != orgRoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can remove owners"})
+			return
+		}
+	}
+	if memberRole == orgRoleOwner {
+		owners, err := countOrgOwners(context.Background(), org.ID)
+		if err != nil {
+			log.Printf("Error counting owners of organization %s: %v", org.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+			return
+		}
+		if owners <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "An organization needs at least one owner. Make someone else an owner first."})
+			return
+		}
+	}
+
+	// Sessions acting in the organization fall back to the public space on
+	// their next request, since the active organization is checked against
+	// the user's memberships
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": member.ID},
+		bson.M{
+			"$pull": bson.M{"memberships": bson.M{"org_id": org.ID}},
+			"$set":  bson.M{"updatedAt": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		log.Printf("Error removing %s from organization %s: %v", member.Email, org.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	log.Printf("User %s removed %s from organization %s", caller.Email, member.Email, org.ID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// findOrgMember loads the member in the userId path parameter, writing a 404
+// if they are not in the organization.
+func findOrgMember(c *gin.Context, orgID primitive.ObjectID) (*models.User, bool) {
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return nil, false
+	}
+	var member models.User
+	err = database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": memberID, "memberships.org_id": orgID}).Decode(&member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return nil, false
+		}
+		log.Printf("Error finding member %s of organization %s: %v", memberID.Hex(), orgID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load member"})
+		return nil, false
+	}
+	return &member, true
+}
+
+func newOrgMemberResponse(user *models.User, orgID primitive.ObjectID) models.OrgMemberResponse {
+	membership := orgMembership(user, orgID)
+	return models.OrgMemberResponse{
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     membership.Role,
+		JoinedAt: membership.JoinedAt,
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+	"mock-orbit/backend/internal/throttle"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// frontendLink builds a link to a frontend page, falling back to the API's
+// public URL when no frontend is configured.
+func frontendLink(path string) string {
+	base := config.AppConfig.FrontendURL
+	if base == "" {
+		base = config.AppConfig.PublicURL
+	}
+	return base + path
+}
+
+// Reset requests a client IP can make before it backs off.
+const passwordResetFreeIPRequests = 5
+
+func ipPasswordResetLimiter() *throttle.Limiter {
+	return &throttle.Limiter{Name: "password-reset:ip", Policy: throttle.Policy{
+		FreeAttempts: passwordResetFreeIPRequests,
+		BaseDelay:    config.AppConfig.PasswordResetResendInterval,
+		MaxDelay:     time.Hour,
+	}}
+}
+
+// ForgotPasswordHandler emails a time-limited, single-use password reset link.
+// It responds identically whether or not the email is registered. Every
+// request counts against the client IP, and an account gets at most one
+// email per PasswordResetResendInterval.
+func ForgotPasswordHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	var input models.ForgotPasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	// Store errors let the request through, as for logins
+	limiter := ipPasswordResetLimiter()
+	if wait, _, err := limiter.Check(context.Background(), c.ClientIP()); err != nil {
+		log.Printf("Error checking %s throttle for %s: %v", limiter.Name, c.ClientIP(), err)
+	} else if wait > 0 {
+		log.Printf("Password reset request throttled (%s %s, retry after %s)", limiter.Name, c.ClientIP(), wait)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many password reset requests. Please wait before trying again."})
+		return
+	}
+	if _, _, err := limiter.Fail(context.Background(), c.ClientIP()); err != nil {
+		log.Printf("Error recording password reset request from %s: %v", c.ClientIP(), err)
+	}
+
+	genericResponse := gin.H{"message": "If an account exists for that email, a password reset link has been sent."}
+
+	// Claim the send slot atomically so concurrent requests cannot both send.
+	// A throttled account gets the generic response too, so the limit doesn't
+	// reveal which emails are registered.
+	interval := config.AppConfig.PasswordResetResendInterval
+	now := time.Now().UTC()
+	var user models.User
+	err := userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{
+			"email": input.Email,
+			"$or": []bson.M{
+				{"password_reset_sent_at": nil},
+				{"password_reset_sent_at": bson.M{"$lte": now.Add(-interval)}},
+			},
+		},
+		bson.M{"$set": bson.M{"password_reset_sent_at": now}},
+	).Decode(&user)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error claiming password reset for %s: %v", input.Email, err)
+		} else {
+			log.Printf("Password reset for %s not sent: unknown email or requested too recently", input.Email)
+		}
+		c.JSON(http.StatusOK, genericResponse)
+		return
+	}
+
+	token, err := auth.NewActionToken(auth.PurposePasswordReset, user.ID, config.AppConfig.PasswordResetTTL, map[string]interface{}{
+		"pwh": auth.PasswordFingerprint(user.Password),
+	})
+	if err != nil {
+		log.Printf("Error creating password reset token for user %s: %v", user.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+
+	link := frontendLink("/auth/reset-password?token=" + url.QueryEscape(token))
+	err = mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Mock Orbit password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and can only be used once. If you did not request a reset, you can ignore this email.\n",
+			user.Name, link, config.AppConfig.PasswordResetTTL),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset email to %s: %v", user.Email, err)
+	} else {
+		log.Printf("Password reset email sent to %s", user.Email)
+	}
+	c.JSON(http.StatusOK, genericResponse)
+}
+
+// ResetPasswordHandler sets a new password from a reset token and invalidates
+// all of the user's existing sessions and tokens.
+func ResetPasswordHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	var input models.ResetPasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	claims, err := auth.ParseActionToken(auth.PurposePasswordReset, input.Token)
+	if err != nil {
+		log.Printf("Password reset failed: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+	userID, err := auth.ClaimObjectID(claims, "user_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+			return
+		}
+		log.Printf("Error finding user %s for password reset: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	// The password changed since the link was issued
+	if fingerprint, _ := claims["pwh"].(string); fingerprint != auth.PasswordFingerprint(user.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This reset link has already been used"})
+			return
+		}
+		log.Printf("Error consuming password reset token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	if err := setPassword(context.Background(), &user, input.NewPassword); err != nil {
+		log.Printf("Error setting new password for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	if err := revokeAllSessions(context.Background(), userID); err != nil {
+		log.Printf("Error revoking sessions after password reset for user %s: %v", userID.Hex(), err)
+	}
+
+	log.Printf("Password reset completed for user %s", user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in with your new password."})
+}
+
+// ChangePasswordHandler changes the current user's password after checking the existing one.
+func ChangePasswordHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.ChangePasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for password change: %v", userID.Hex(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		log.Printf("Password change rejected for user %s: current password incorrect", userID.Hex())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	if err := setPassword(context.Background(), &user, input.NewPassword); err != nil {
+		log.Printf("Error changing password for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	log.Printf("Password changed for user %s", user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// setPassword hashes and stores a new password, then notifies the user by email.
+func setPassword(ctx context.Context, user *models.User, newPassword string) error {
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	_, err = database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"password": hashedPassword, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Mock Orbit password was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nThe password for your Mock Orbit account was just changed. If this was not you, reset your password immediately.\n", user.Name),
+	})
+	if err != nil {
+		log.Printf("Error sending password change notice to %s: %v", user.Email, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	patDefaultLifetimeDays = 30
+	maxActivePATsPerUser   = 50
+)
+
+// ListPersonalAccessTokensHandler lists the current user's active tokens.
+// Token values are never shown again after creation.
+func ListPersonalAccessTokensHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	cursor, err := database.GetCollection("personal_access_tokens").Find(context.Background(),
+		bson.M{"user_id": userID, "revoked_at": nil, "expires_at": bson.M{"$gt": time.Now().UTC()}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		log.Printf("Error listing personal access tokens for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+		return
+	}
+	var tokens []models.PersonalAccessToken
+	if err := cursor.All(context.Background(), &tokens); err != nil {
+		log.Printf("Error decoding personal access tokens for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+		return
+	}
+
+	response := make([]models.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, newPersonalAccessTokenResponse(&tokens[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePersonalAccessTokenHandler creates a scoped token. The token value is
+// returned in this response only; just its hash is stored.
+func CreatePersonalAccessTokenHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	collection := database.GetCollection("personal_access_tokens")
+	var input models.CreatePersonalAccessTokenInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+	scopes := make([]string, 0, len(input.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range input.Scopes {
+		if !auth.IsValidPATScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope, "valid_scopes": auth.PATScopes})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = patDefaultLifetimeDays
+	}
+
+	now := time.Now().UTC()
+	active, err := collection.CountDocuments(context.Background(), bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		log.Printf("Error counting personal access tokens for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	if active >= maxActivePATsPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many active tokens. Revoke an unused token first."})
+		return
+	}
+
+	raw, hash, err := auth.NewPersonalAccessToken()
+	if err != nil {
+		log.Printf("Error generating personal access token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	pat := models.PersonalAccessToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      input.Name,
+		TokenHash: hash,
+		Hint:      raw[len(raw)-4:],
+		Scopes:    scopes,
+		OrgID:     tenantID(c), // The token acts in the organization the session is acting in
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+	if _, err := collection.InsertOne(context.Background(), pat); err != nil {
+		log.Printf("Error storing personal access token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	log.Printf("User %s created personal access token %s (%v)", userID.Hex(), pat.ID.Hex(), scopes)
+	c.JSON(http.StatusCreated, gin.H{
+		"token":   raw,
+		"details": newPersonalAccessTokenResponse(&pat),
+		"message": "Store this token now. It will not be shown again.",
+	})
+}
+
+// RevokePersonalAccessTokenHandler revokes one of the current user's tokens.
+func RevokePersonalAccessTokenHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("tokenId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID format"})
+		return
+	}
+
+	result, err := database.GetCollection("personal_access_tokens").UpdateOne(context.Background(),
+		bson.M{"_id": tokenID, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error revoking personal access token %s: %v", tokenID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	log.Printf("User %s revoked personal access token %s", userID.Hex(), tokenID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+func newPersonalAccessTokenResponse(pat *models.PersonalAccessToken) models.PersonalAccessTokenResponse {
+	return models.PersonalAccessTokenResponse{
+		ID:         pat.ID,
+		Name:       pat.Name,
+		Hint:       pat.Hint,
+		Scopes:     pat.Scopes,
+		OrgID:      pat.OrgID,
+		ExpiresAt:  pat.ExpiresAt,
+		LastUsedAt: pat.LastUsedAt,
+		CreatedAt:  pat.CreatedAt,
+	}
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Interviews are booked in hourly slots (see GetAvailabilityHandler), so two
+// interviews of the same person closer than this overlap.
+const interviewSlotLength = time.Hour
+
+var (
+	errProposalChanged         = errors.New("reschedule: proposal no longer pending")
+	errScheduleConflict        = errors.New("interview: participant already booked")
+	errTransactionsUnsupported = errors.New("interview: database does not support transactions")
+)
+
+// RescheduleProposalsHandler negotiates a new time for a scheduled interview
+// between its participants. Either one proposes up to five times; the other
+// accepts one of them, declines, or counters with times of their own. Every
+// proposal is kept on the interview. Accepting checks both participants'
+// schedules and moves the interview in one transaction.
+func RescheduleProposalsHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.RescheduleProposalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	interviewID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+
+	var interview models.Interview
+	err = database.GetCollection("interviews").FindOne(context.Background(), bson.M{"_id": interviewID, "org_id": tenantID(c)}).Decode(&interview)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
+			return
+		}
+		log.Printf("Error finding interview %s: %v", interviewID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reschedule proposals"})
+		return
+	}
+	if participantRole(&interview, userID) == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this interview"})
+		return
+	}
+	if interview.Status != "scheduled" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only scheduled interviews can be rescheduled", "code": "invalid_transition", "status": interview.Status})
+		return
+	}
+	if interview.IntervieweeID.IsZero() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Interviews with a guest interviewee can't be rescheduled; cancel and schedule a new one instead"})
+		return
+	}
+	otherID := interview.InterviewerID
+	if userID == otherID {
+		otherID = interview.IntervieweeID
+	}
+
+	if input.Action != "decline" {
+		blocked, err := isBlocked(context.Background(), userID, otherID)
+		if err != nil {
+			log.Printf("Error checking blocks between %s and %s: %v", userID.Hex(), otherID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reschedule proposals"})
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can't reschedule an interview with this user", "code": "user_blocked"})
+			return
+		}
+	}
+
+	// The proposal being answered must be the pending one, from the other participant
+	pending := pendingProposal(&interview)
+	if input.Action == "propose" {
+		if pending != nil && pending.ProposedBy != userID {
+			c.JSON(http.StatusConflict, gin.H{"error": "The other participant has proposed new times; accept, decline or counter them", "code": "proposal_pending", "proposal_id": pending.ID})
+			return
+		}
+	} else if pending == nil || pending.ID.Hex() != input.ProposalID {
+		c.JSON(http.StatusConflict, gin.H{"error": "This proposal is no longer pending", "code": "proposal_changed"})
+		return
+	} else if pending.ProposedBy == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the other participant can answer your proposal"})
+		return
+	}
+
+	now := time.Now().UTC()
+	proposals := append([]models.RescheduleProposal(nil), interview.RescheduleProposals...)
+	var closed *models.RescheduleProposal // The pending proposal this request closes, if any
+	if pending != nil {
+		for i := range proposals {
+			if proposals[i].ID == pending.ID {
+				closed = &proposals[i]
+			}
+		}
+		closed.RespondedBy = &userID
+		closed.RespondedAt = &now
+		closed.ResponseMessage = input.Message
+	}
+
+	var updated *models.Interview
+	switch input.Action {
+	case "propose", "counter":
+		times, msg := checkProposedTimes(input.Times, interview.ScheduledTime, now)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if closed != nil {
+			closed.Status = "countered"
+			if input.Action == "propose" {
+				closed.Status = "withdrawn" // Replaced by the proposer
+			}
+			closed.ResponseMessage = ""
+		}
+		proposal := models.RescheduleProposal{
+			ID:           primitive.NewObjectID(),
+			ProposedBy:   userID,
+			Times:        times,
+			Message:      input.Message,
+			PreviousTime: interview.ScheduledTime,
+			Status:       "pending",
+			CreatedAt:    now,
+		}
+		proposals = append(proposals, proposal)
+		updated, err = saveProposals(context.Background(), &interview, pending, proposals)
+
+	case "decline":
+		closed.Status = "declined"
+		updated, err = saveProposals(context.Background(), &interview, pending, proposals)
+
+	case "accept":
+		if input.Time == nil || !containsTime(pending.Times, *input.Time) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "time must be one of the proposed times"})
+			return
+		}
+		newTime := input.Time.UTC()
+		if !newTime.After(now) {
+			c.JSON(http.StatusConflict, gin.H{"error": "This time has already passed; counter with new times instead", "code": "time_passed"})
+			return
+		}
+		closed.Status = "accepted"
+		closed.AcceptedTime = &newTime
+		updated, err = acceptProposal(context.Background(), &interview, pending, proposals, newTime)
+	}
+	if err != nil {
+		switch err {
+		case errProposalChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": "The interview or its proposals changed meanwhile; reload and try again", "code": "proposal_changed"})
+		case errScheduleConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "You or the other participant already have an interview at that time", "code": "schedule_conflict"})
+		case errTransactionsUnsupported:
+			log.Printf("Can't reschedule interview %s: MongoDB must run as a replica set to use transactions", interviewID.Hex())
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Rescheduling is unavailable because the database doesn't support transactions", "code": "transactions_unsupported"})
+		default:
+			log.Printf("Error updating reschedule proposals of interview %s: %v", interviewID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reschedule proposals"})
+		}
+		return
+	}
+
+	log.Printf("User %s made reschedule action %q on interview %s", userID.Hex(), input.Action, interviewID.Hex())
+	notifyReschedule(context.Background(), updated, userID, otherID, input.Action)
+	c.JSON(http.StatusOK, newInterviewResponse(updated, userID))
+}
+
+// pendingProposal returns the interview's open proposal, if any.
+func pendingProposal(interview *models.Interview) *models.RescheduleProposal {
+	for i := range interview.RescheduleProposals {
+		if interview.RescheduleProposals[i].Status == "pending" {
+			return &interview.RescheduleProposals[i]
+		}
+	}
+	return nil
+}
+
+// proposalsUnchanged matches the interview while it is scheduled and still has
+// the pending proposal the caller saw (or none).
+func proposalsUnchanged(interview *models.Interview, pending *models.RescheduleProposal) bson.M {
+	filter := bson.M{"_id": interview.ID, "status": "scheduled", "scheduled_time": interview.ScheduledTime}
+	if pending != nil {
+		filter["reschedule_proposals"] = bson.M{"$elemMatch": bson.M{"id": pending.ID, "status": "pending"}}
+	} else {
+		filter["reschedule_proposals.status"] = bson.M{"$ne": "pending"}
+	}
+	return filter
+}
+
+// saveProposals stores the updated proposal list unless another request
+// changed it first.
+func saveProposals(ctx context.Context, interview *models.Interview, pending *models.RescheduleProposal, proposals []models.RescheduleProposal) (*models.Interview, error) {
+	var updated models.Interview
+	err := database.GetCollection("interviews").FindOneAndUpdate(ctx,
+		proposalsUnchanged(interview, pending),
+		bson.M{"$set": bson.M{"reschedule_proposals": proposals, "updatedAt": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, errProposalChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// acceptProposal moves the interview to newTime and stores the proposals in a
+// scheduling transaction that first reserves the new time for both
+// participants.
+func acceptProposal(ctx context.Context, interview *models.Interview, pending *models.RescheduleProposal, proposals []models.RescheduleProposal, newTime time.Time) (*models.Interview, error) {
+	result, err := withScheduleTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		participants := []primitive.ObjectID{interview.InterviewerID, interview.IntervieweeID}
+		if err := reserveInterviewSlot(sc, participants, newTime, interview.ID); err != nil {
+			return nil, err
+		}
+
+		var updated models.Interview
+		err := database.GetCollection("interviews").FindOneAndUpdate(sc,
+			proposalsUnchanged(interview, pending),
+			bson.M{"$set": bson.M{"scheduled_time": newTime, "reschedule_proposals": proposals, "updatedAt": time.Now().UTC()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err == mongo.ErrNoDocuments {
+			return nil, errProposalChanged
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.Interview), nil
+}
+
+// withScheduleTransaction runs fn in a MongoDB transaction. Transactions need
+// a replica set; on a standalone server it fails with
+// errTransactionsUnsupported.
+func withScheduleTransaction(ctx context.Context, fn func(sc mongo.SessionContext) (interface{}, error)) (interface{}, error) {
+	session, err := database.MongoClient.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, fn)
+	var serverErr mongo.ServerError
+	// IllegalOperation, sent by standalone servers
+	if errors.As(err, &serverErr) && serverErr.HasErrorCodeWithMessage(20, "Transaction numbers") {
+		return nil, errTransactionsUnsupported
+	}
+	return result, err
+}
+
+// reserveInterviewSlot fails with errScheduleConflict if any of the
+// participants has another scheduled or running interview within
+// interviewSlotLength of at. It bumps their schedule versions first, so of two
+// transactions booking the same person concurrently one conflicts, retries
+// and sees the other's interview. Guest slots (the nil ID) are skipped.
+func reserveInterviewSlot(sc mongo.SessionContext, participants []primitive.ObjectID, at time.Time, excludeID primitive.ObjectID) error {
+	var users []primitive.ObjectID
+	for _, id := range participants {
+		if !id.IsZero() {
+			users = append(users, id)
+		}
+	}
+	if _, err := database.GetCollection("users").UpdateMany(sc,
+		bson.M{"_id": bson.M{"$in": users}},
+		bson.M{"$inc": bson.M{"schedule_version": 1}},
+	); err != nil {
+		return err
+	}
+
+	conflicts, err := database.GetCollection("interviews").CountDocuments(sc, bson.M{
+		"_id":    bson.M{"$ne": excludeID},
+		"status": bson.M{"$in": []string{"scheduled", "in_progress"}},
+		"$or": []bson.M{
+			{"interviewer_id": bson.M{"$in": users}},
+			{"interviewee_id": bson.M{"$in": users}},
+		},
+		"scheduled_time": bson.M{"$gt": at.Add(-interviewSlotLength), "$lt": at.Add(interviewSlotLength)},
+	})
+	if err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return errScheduleConflict
+	}
+	return nil
+}
+
+// checkProposedTimes normalizes the times of a new proposal as they will be
+// stored and validates them, returning what is wrong with them, or "".
+func checkProposedTimes(proposed []time.Time, current, now time.Time) ([]time.Time, string) {
+	if len(proposed) == 0 {
+		return nil, "Propose at least one time"
+	}
+	times := normalizeTimes(proposed)
+	for i, t := range times {
+		if !t.After(now) {
+			return nil, "Proposed times must be in the future"
+		}
+		if t.Equal(current) {
+			return nil, "The interview is already scheduled at " + t.Format(time.RFC3339)
+		}
+		for _, other := range times[:i] {
+			if t.Equal(other) {
+				return nil, "Each time can only be proposed once"
+			}
+		}
+	}
+	return times, ""
+}
+
+func normalizeTimes(times []time.Time) []time.Time {
+	normalized := make([]time.Time, len(times))
+	for i, t := range times {
+		// Mongo stores milliseconds; truncate so accepting a returned time matches
+		normalized[i] = t.UTC().Truncate(time.Millisecond)
+	}
+	return normalized
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, candidate := range times {
+		if candidate.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyReschedule emails the other participant about a reschedule action.
+// Failures are logged; the interview shows the proposals either way.
+func notifyReschedule(ctx context.Context, interview *models.Interview, actorID, recipientID primitive.ObjectID, action string) {
+	var recipient models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": recipientID}).Decode(&recipient); err != nil {
+		log.Printf("Error loading user %s to notify about a reschedule: %v", recipientID.Hex(), err)
+		return
+	}
+	actorName := interview.InterviewerName
+	if actorID == interview.IntervieweeID {
+		actorName = interview.IntervieweeName
+	}
+	link := frontendLink("/dashboard")
+
+	var subject, summary string
+	switch action {
+	case "propose", "counter":
+		subject = "New times proposed for your Mock Orbit interview"
+		summary = fmt.Sprintf("%s proposed new times for your %s interview, currently scheduled for %s UTC. Accept one, decline, or suggest others:", actorName, interview.Topic, interview.ScheduledTime.UTC().Format("2006-01-02 15:04"))
+	case "accept":
+		subject = "Your Mock Orbit interview has been rescheduled"
+		summary = fmt.Sprintf("%s accepted your proposal. Your %s interview is now scheduled for %s UTC:", actorName, interview.Topic, interview.ScheduledTime.UTC().Format("2006-01-02 15:04"))
+	case "decline":
+		subject = "Your reschedule proposal was declined"
+		summary = fmt.Sprintf("%s declined your proposal. Your %s interview stays scheduled for %s UTC:", actorName, interview.Topic, interview.ScheduledTime.UTC().Format("2006-01-02 15:04"))
+	}
+	err := mailer.Send(ctx, mailer.Message{
+		To:      recipient.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\n%s\n\n%s\n", recipient.Name, summary, link),
+	})
+	if err != nil {
+		log.Printf("Error sending reschedule notice to %s: %v", recipient.Email, err)
+	}
+}
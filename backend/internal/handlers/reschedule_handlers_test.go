@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckProposedTimes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	current := now.Add(24 * time.Hour)
+	tomorrow, dayAfter := now.Add(25*time.Hour), now.Add(49*time.Hour)
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  string
+	}{
+		{"one time", []time.Time{tomorrow}, ""},
+		{"several times", []time.Time{tomorrow, dayAfter}, ""},
+		{"no times", nil, "Propose at least one time"},
+		{"time now", []time.Time{now}, "Proposed times must be in the future"},
+		{"time in the past", []time.Time{tomorrow, now.Add(-time.Minute)}, "Proposed times must be in the future"},
+		{"current time", []time.Time{current}, "The interview is already scheduled at 2026-01-02T12:00:00Z"},
+		{"current time in another zone", []time.Time{current.In(time.FixedZone("CET", 3600))}, "The interview is already scheduled at 2026-01-02T12:00:00Z"},
+		{"duplicate time", []time.Time{tomorrow, dayAfter, tomorrow}, "Each time can only be proposed once"},
+		{"duplicate in another zone", []time.Time{tomorrow, tomorrow.In(time.FixedZone("PST", -8*3600))}, "Each time can only be proposed once"},
+		{"duplicate below a millisecond", []time.Time{tomorrow.Add(100 * time.Microsecond), tomorrow.Add(900 * time.Microsecond)}, "Each time can only be proposed once"},
+		{"current time below a millisecond", []time.Time{current.Add(500 * time.Microsecond)}, "The interview is already scheduled at 2026-01-02T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			times, got := checkProposedTimes(tt.times, current, now)
+			if got != tt.want {
+				t.Errorf("checkProposedTimes() = %q, want %q", got, tt.want)
+			}
+			if got == "" && len(times) != len(tt.times) {
+				t.Errorf("checkProposedTimes() returned %d times, want %d", len(times), len(tt.times))
+			}
+			for _, stored := range times {
+				if stored.Location() != time.UTC || stored.Nanosecond()%int(time.Millisecond) != 0 {
+					t.Errorf("time %v is not normalized", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeTimes(t *testing.T) {
+	in := time.Date(2026, 1, 1, 13, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	got := normalizeTimes([]time.Time{in})
+	want := time.Date(2026, 1, 1, 12, 0, 0, 123000000, time.UTC)
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("normalizeTimes() = %v, want [%v]", got, want)
+	}
+	if !containsTime(got, in.Truncate(time.Millisecond)) || containsTime(got, in.Add(time.Millisecond)) {
+		t.Errorf("containsTime() should match the same instant only")
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	roleRequestPending  = "pending"
+	roleRequestApproved = "approved"
+	roleRequestRejected = "rejected"
+)
+
+// CreateRoleRequestHandler asks for an additional role. The request is approved
+// straight away if the configured auto-approval rules for the role pass;
+// otherwise it waits in the admin queue.
+func CreateRoleRequestHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.RoleRequestInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s for role request: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit role request"})
+		return
+	}
+	for _, role := range user.AvailableRoles {
+		if role == input.Role {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have this role"})
+			return
+		}
+	}
+
+	request := models.RoleRequest{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		UserName:  user.Name,
+		UserEmail: user.Email,
+		Role:      input.Role,
+		Reason:    input.Reason,
+		Status:    roleRequestPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	// A partial unique index allows only one pending request per user and role
+	if _, err := database.GetCollection("role_requests").InsertOne(context.Background(), request); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have a pending request for this role"})
+			return
+		}
+		log.Printf("Error storing role request for user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit role request"})
+		return
+	}
+
+	eligible, err := meetsRoleApprovalRules(context.Background(), &user, input.Role)
+	if err != nil {
+		// The request is queued either way; an admin can still approve it
+		log.Printf("Error evaluating role approval rules for user %s: %v", user.Email, err)
+	}
+	if eligible {
+		decided, err := decideRoleRequest(context.Background(), request.ID, roleRequestApproved, nil, "Approved automatically")
+		if err != nil {
+			log.Printf("Error auto-approving role request %s: %v", request.ID.Hex(), err)
+		} else {
+			log.Printf("Role request %s (%s for %s) approved automatically", request.ID.Hex(), request.Role, user.Email)
+			c.JSON(http.StatusCreated, decided)
+			return
+		}
+	}
+
+	log.Printf("User %s requested role %s (request %s)", user.Email, input.Role, request.ID.Hex())
+	c.JSON(http.StatusCreated, request)
+}
+
+// ListMyRoleRequestsHandler lists the current user's role requests, newest first.
+func ListMyRoleRequestsHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	listRoleRequests(c, bson.M{"user_id": userID})
+}
+
+// ListRoleRequestsHandler is the admin queue. It shows pending requests unless
+// ?status= asks for approved, rejected or all.
+func ListRoleRequestsHandler(c *gin.Context) {
+	filter := bson.M{}
+	switch status := c.DefaultQuery("status", roleRequestPending); status {
+	case roleRequestPending, roleRequestApproved, roleRequestRejected:
+		filter["status"] = status
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be pending, approved, rejected or all"})
+		return
+	}
+	listRoleRequests(c, filter)
+}
+
+func listRoleRequests(c *gin.Context, filter bson.M) {
+	cursor, err := database.GetCollection("role_requests").Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(200),
+	)
+	if err != nil {
+		log.Printf("Error listing role requests: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve role requests"})
+		return
+	}
+	requests := []models.RoleRequest{}
+	if err := cursor.All(context.Background(), &requests); err != nil {
+		log.Printf("Error decoding role requests: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve role requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveRoleRequestHandler grants the requested role.
+func ApproveRoleRequestHandler(c *gin.Context) {
+	decideRoleRequestHandler(c, roleRequestApproved)
+}
+
+// RejectRoleRequestHandler declines the request, optionally with a note for the user.
+func RejectRoleRequestHandler(c *gin.Context) {
+	decideRoleRequestHandler(c, roleRequestRejected)
+}
+
+func decideRoleRequestHandler(c *gin.Context, status string) {
+	adminID := c.MustGet("userObjectID").(primitive.ObjectID)
+	var input models.RoleDecisionInput
+	if !bindOptionalJSON(c, &input) {
+		return
+	}
+
+	requestID, err := primitive.ObjectIDFromHex(c.Param("requestId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID format"})
+		return
+	}
+
+	decided, err := decideRoleRequest(context.Background(), requestID, status, &adminID, input.Note)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No pending role request with this ID"})
+			return
+		}
+		log.Printf("Error deciding role request %s: %v", requestID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role request"})
+		return
+	}
+
+	action := "role_request.approve"
+	if status == roleRequestRejected {
+		action = "role_request.reject"
+	}
+	recordAudit(c, action, "role_request", requestID.Hex(), map[string]interface{}{
+		"user_id": decided.UserID.Hex(),
+		"role":    decided.Role,
+		"note":    input.Note,
+	})
+	log.Printf("Admin %s %s role request %s (%s for %s)", adminID.Hex(), status, requestID.Hex(), decided.Role, decided.UserEmail)
+	c.JSON(http.StatusOK, decided)
+}
+
+// decideRoleRequest moves a pending request to approved or rejected and, on
+// approval, adds the role to the user. The new role shows up in the user's
+// next access token. adminID is nil for automatic decisions. It returns
+// mongo.ErrNoDocuments if the request is not pending.
+func decideRoleRequest(ctx context.Context, requestID primitive.ObjectID, status string, adminID *primitive.ObjectID, note string) (*models.RoleRequest, error) {
+	requests := database.GetCollection("role_requests")
+	now := time.Now().UTC()
+
+	set := bson.M{"status": status, "decided_at": now}
+	if adminID != nil {
+		set["decided_by"] = *adminID
+	} else {
+		set["auto_approved"] = true
+	}
+	if note != "" {
+		set["decision_note"] = note
+	}
+
+	// Claiming the pending request first means two admins can't both decide it
+	var request models.RoleRequest
+	err := requests.FindOneAndUpdate(ctx,
+		bson.M{"_id": requestID, "status": roleRequestPending},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == roleRequestApproved {
+		_, err := database.GetCollection("users").UpdateOne(ctx,
+			bson.M{"_id": request.UserID},
+			bson.M{
+				"$addToSet": bson.M{"availableRoles": request.Role},
+				"$set":      bson.M{"updatedAt": now},
+			},
+		)
+		if err != nil {
+			// Put the request back in the queue so it can be retried
+			_, revertErr := requests.UpdateOne(ctx,
+				bson.M{"_id": requestID},
+				bson.M{
+					"$set":   bson.M{"status": roleRequestPending},
+					"$unset": bson.M{"decided_at": "", "decided_by": "", "auto_approved": "", "decision_note": ""},
+				},
+			)
+			if revertErr != nil {
+				log.Printf("Error returning role request %s to the queue: %v", requestID.Hex(), revertErr)
+			}
+			return nil, err
+		}
+	}
+
+	notifyRoleDecision(ctx, &request)
+	return &request, nil
+}
+
+func notifyRoleDecision(ctx context.Context, request *models.RoleRequest) {
+	var msg mailer.Message
+	if request.Status == roleRequestApproved {
+		msg = mailer.Message{
+			To:      request.UserEmail,
+			Subject: "Your Mock Orbit role request was approved",
+			Body:    fmt.Sprintf("Hi %s,\n\nYou can now use Mock Orbit as an %s. The new role appears the next time the app refreshes your session.\n", request.UserName, request.Role),
+		}
+	} else {
+		body := fmt.Sprintf("Hi %s,\n\nYour request to become an %s on Mock Orbit was not approved.\n", request.UserName, request.Role)
+		if request.DecisionNote != "" {
+			body += "\nNote from the reviewer: " + request.DecisionNote + "\n"
+		}
+		msg = mailer.Message{
+			To:      request.UserEmail,
+			Subject: "Your Mock Orbit role request",
+			Body:    body,
+		}
+	}
+	if err := mailer.Send(ctx, msg); err != nil {
+		log.Printf("Error sending role decision email to %s: %v", request.UserEmail, err)
+	}
+}
+
+// meetsRoleApprovalRules reports whether the user passes every configured
+// auto-approval rule for the role. Roles without rules always need an admin.
+func meetsRoleApprovalRules(ctx context.Context, user *models.User, role string) (bool, error) {
+	matched := false
+	for _, rule := range config.AppConfig.RoleAutoApprovalRules {
+		if rule.Role != role {
+			continue
+		}
+		matched = true
+
+		var value int64
+		switch rule.Metric {
+		case "completed_as_interviewee", "completed_as_interviewer":
+			field := "interviewee_id"
+			if rule.Metric == "completed_as_interviewer" {
+				field = "interviewer_id"
+			}
+			count, err := database.GetCollection("interviews").CountDocuments(ctx, bson.M{
+				field:    user.ID,
+				"status": "completed",
+			})
+			if err != nil {
+				return false, err
+			}
+			value = count
+		case "account_age_days":
+			value = int64(time.Since(user.CreatedAt).Hours() / 24)
+		default:
+			return false, nil
+		}
+
+		if value < int64(rule.Threshold) {
+			return false, nil
+		}
+	}
+	return matched, nil
+}
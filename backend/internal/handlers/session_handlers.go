@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListSessionsHandler lists the devices the current user is logged in on.
+func ListSessionsHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	currentSessionID := c.MustGet("sessionID").(primitive.ObjectID)
+
+	sessions, err := auth.ListSessions(context.Background(), userID)
+	if err != nil {
+		log.Printf("Error listing sessions for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	response := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, models.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSessionHandler logs one of the current user's sessions out. Revoking
+// the current session works like logging out.
+func RevokeSessionHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	revoked, err := revokeSession(context.Background(), userID, sessionID)
+	if err != nil {
+		log.Printf("Error revoking session %s for user %s: %v", sessionID.Hex(), userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	log.Printf("User %s revoked session %s", userID.Hex(), sessionID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// SetActiveRoleHandler switches the role the current session acts in and
+// returns an access token carrying it. The token the request was made with is
+// revoked, so the session never holds live tokens for two roles.
+func SetActiveRoleHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	sessionID := c.MustGet("sessionID").(primitive.ObjectID)
+	var input models.SetActiveRoleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s to switch active role: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch role"})
+		return
+	}
+	if auth.ResolveActiveRole(&user, input.Role) != input.Role {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have the " + input.Role + " role", "available_roles": user.AvailableRoles})
+		return
+	}
+
+	if err := auth.SetSessionActiveRole(context.Background(), userID, sessionID, input.Role); err != nil {
+		if err == auth.ErrRevokedToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
+		log.Printf("Error switching active role of session %s: %v", sessionID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch role"})
+		return
+	}
+
+	tokens, err := issueAccessToken(&user, sessionID, input.Role, tenantID(c))
+	if err != nil {
+		log.Printf("Error issuing access token for user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch role"})
+		return
+	}
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.MustGet("tokenExpiresAt").(time.Time)
+	if err := auth.RevokeToken(context.Background(), tokenID, userID, expiresAt); err != nil {
+		// The old token expires soon anyway; the switch itself succeeded
+		log.Printf("Error revoking previous access token of session %s: %v", sessionID.Hex(), err)
+	}
+
+	log.Printf("User %s switched session %s to role %s", user.Email, sessionID.Hex(), input.Role)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// SetActiveOrgHandler switches the organization the current session acts in,
+// or back to the public space when no org_id is given, and returns an access
+// token carrying it. Like SetActiveRoleHandler it revokes the token the
+// request was made with.
+func SetActiveOrgHandler(c *gin.Context) {
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	sessionID := c.MustGet("sessionID").(primitive.ObjectID)
+	var input models.SetActiveOrgInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error finding user %s to switch organization: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+	var orgID *primitive.ObjectID
+	if input.OrgID != "" {
+		requested, _ := primitive.ObjectIDFromHex(input.OrgID) // Validated by binding
+		orgID = auth.ResolveActiveOrg(&user, &requested)
+		if orgID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this organization"})
+			return
+		}
+	}
+
+	if err := auth.SetSessionActiveOrg(context.Background(), userID, sessionID, orgID); err != nil {
+		if err == auth.ErrRevokedToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
+		log.Printf("Error switching organization of session %s: %v", sessionID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+
+	tokens, err := issueAccessToken(&user, sessionID, c.GetString("activeRole"), orgID)
+	if err != nil {
+		log.Printf("Error issuing access token for user %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.MustGet("tokenExpiresAt").(time.Time)
+	if err := auth.RevokeToken(context.Background(), tokenID, userID, expiresAt); err != nil {
+		log.Printf("Error revoking previous access token of session %s: %v", sessionID.Hex(), err)
+	}
+
+	if orgID == nil {
+		log.Printf("User %s switched session %s to the public space", user.Email, sessionID.Hex())
+	} else {
+		log.Printf("User %s switched session %s to organization %s", user.Email, sessionID.Hex(), orgID.Hex())
+	}
+	c.JSON(http.StatusOK, tokens)
+}
@@ -2,9 +2,16 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"sort" // Added for sorting topics
+	"strconv"
+	"strings"
 	"time"
 
 	"mock-orbit/backend/internal/database"
@@ -13,7 +20,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	// "go.mongodb.org/mongo-driver/mongo" // Import mongo package
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -43,16 +50,7 @@ func GetUserProfileHandler(c *gin.Context) {
 	}
 
 	// Return user info (excluding password)
-	userResponse := models.UserResponse{ // Use UserResponse DTO
-		ID:                user.ID,
-		Name:              user.Name,
-		Email:             user.Email,
-		Role:              user.Role,
-		AvailableRoles:    user.AvailableRoles,
-		ProfilePictureURL: user.ProfilePictureURL,
-		CreatedAt:         user.CreatedAt,
-		UpdatedAt:         user.UpdatedAt,
-	}
+	userResponse := newUserResponse(&user)
 	c.JSON(http.StatusOK, userResponse)
 }
 
@@ -84,8 +82,17 @@ func UpdateUserProfileHandler(c *gin.Context) {
 			return
 		}
 		updateFields["name"] = *input.Name
+		// Queued in the same write, so the interviews catch up even if this
+		// process stops right after it
+		updateFields["name_sync"] = newNameSyncJob(*input.Name, time.Now().UTC())
 	}
 	if input.ProfilePictureURL != nil {
+		// An uploaded picture is replaced by the external URL, or cleared with it
+		if err := removeAvatar(context.Background(), userID); err != nil {
+			log.Printf("Error removing uploaded avatar for user %s: %v", userIDHex, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
 		if *input.ProfilePictureURL == "" {
              updateFields["profile_picture_url"] = nil // Allow setting to null/empty
         } else {
@@ -94,7 +101,86 @@ func UpdateUserProfileHandler(c *gin.Context) {
         }
 	}
 
-	if len(updateFields) == 0 {
+	unsetFields := bson.M{}
+	if input.Timezone != nil {
+		if *input.Timezone == "" {
+			unsetFields["timezone"] = ""
+		} else if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "Local" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone. Use an IANA name such as Europe/Berlin."})
+			return
+		} else {
+			updateFields["timezone"] = *input.Timezone
+		}
+	}
+	// Lists replace what is stored; an empty one clears the field
+	setList := func(field string, list *[]string) {
+		if list == nil {
+			return
+		}
+		if values := uniqueStrings(*list); len(values) > 0 {
+			updateFields[field] = values
+		} else {
+			unsetFields[field] = ""
+		}
+	}
+	setList("topics", input.Topics)
+	setList("target_roles", input.TargetRoles)
+	setList("target_companies", input.TargetCompanies)
+	setList("languages", input.Languages)
+
+	if input.Skills != nil {
+		skills := []models.Skill{}
+		seen := make(map[string]bool)
+		for _, skill := range *input.Skills {
+			skill.Name = strings.TrimSpace(skill.Name)
+			key := strings.ToLower(skill.Name)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			skills = append(skills, skill)
+		}
+		if len(skills) > 0 {
+			updateFields["skills"] = skills
+		} else {
+			unsetFields["skills"] = ""
+		}
+	}
+	if input.YearsOfExperience != nil {
+		updateFields["years_of_experience"] = *input.YearsOfExperience
+	}
+	if input.Bio != nil {
+		if bio := strings.TrimSpace(*input.Bio); bio != "" {
+			updateFields["bio"] = bio
+		} else {
+			unsetFields["bio"] = ""
+		}
+	}
+	if input.Links != nil {
+		if len(*input.Links) > 0 {
+			updateFields["links"] = *input.Links
+		} else {
+			unsetFields["links"] = ""
+		}
+	}
+	if p := input.Privacy; p != nil {
+		for field, flag := range map[string]*bool{
+			"hide_skills":           p.HideSkills,
+			"hide_experience":       p.HideExperience,
+			"hide_target_roles":     p.HideTargetRoles,
+			"hide_target_companies": p.HideTargetCompanies,
+			"hide_timezone":         p.HideTimezone,
+			"hide_languages":        p.HideLanguages,
+			"hide_bio":              p.HideBio,
+			"hide_links":            p.HideLinks,
+		} {
+			if flag != nil {
+				updateFields["privacy."+field] = *flag
+			}
+		}
+	}
+
+	if len(updateFields) == 0 && len(unsetFields) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No update fields provided"})
 		return
 	}
@@ -103,6 +189,9 @@ func UpdateUserProfileHandler(c *gin.Context) {
 	updateFields["updatedAt"] = time.Now().UTC()
 
 	update := bson.M{"$set": updateFields}
+	if len(unsetFields) > 0 {
+		update["$unset"] = unsetFields
+	}
 	options := options.FindOneAndUpdate().SetReturnDocument(options.After) // Return the updated document
 
 	var updatedUser models.User
@@ -113,60 +202,113 @@ func UpdateUserProfileHandler(c *gin.Context) {
 		return
 	}
 
-    // If name was updated, update denormalized names in future interviews
-	if nameUpdate, ok := updateFields["name"]; ok {
-		go updateDenormalizedNames(userID, nameUpdate.(string)) // Run in background
+	// If name was updated, let the worker copy it into the interviews
+	if _, ok := updateFields["name"]; ok {
+		updateSearchKeys(context.Background(), userID, updatedUser.Name)
+		kickNameSync()
 	}
 
 
 	// Return updated user info (excluding password)
-	userResponse := models.UserResponse{ // Use UserResponse DTO
-		ID:                updatedUser.ID,
-		Name:              updatedUser.Name,
-		Email:             updatedUser.Email,
-		Role:              updatedUser.Role,
-		AvailableRoles:    updatedUser.AvailableRoles,
-		ProfilePictureURL: updatedUser.ProfilePictureURL,
-		CreatedAt:         updatedUser.CreatedAt,
-		UpdatedAt:         updatedUser.UpdatedAt,
-	}
+	userResponse := newUserResponse(&updatedUser)
 
 	log.Printf("User profile updated successfully for: %s", updatedUser.Email)
 	c.JSON(http.StatusOK, userResponse)
 }
 
-// updateDenormalizedNames updates names in interviews scheduled in the future.
-func updateDenormalizedNames(userID primitive.ObjectID, newName string) {
-	interviewCollection := database.GetCollection("interviews") // Get collection inside helper
-	now := time.Now().UTC()
-	filterInterviewer := bson.M{
-		"interviewer_id": userID,
-		"scheduled_time": bson.M{"$gt": now}, // Only update future interviews
+// newUserResponse builds the API representation of a user (excluding credentials).
+func newUserResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:                   user.ID,
+		Name:                 user.Name,
+		Email:                user.Email,
+		Role:                 user.Role,
+		AvailableRoles:       user.AvailableRoles,
+		ProfilePictureURL:    user.ProfilePictureURL,
+		Timezone:             user.Timezone,
+		Topics:               user.Topics,
+		Skills:               user.Skills,
+		YearsOfExperience:    user.YearsOfExperience,
+		TargetRoles:          user.TargetRoles,
+		TargetCompanies:      user.TargetCompanies,
+		Languages:            user.Languages,
+		Bio:                  user.Bio,
+		Links:                user.Links,
+		Privacy:              user.Privacy,
+		EmailVerified:        !user.EmailVerificationPending,
+		MFAEnabled:           user.TOTPEnabled,
+		PendingEmail:         user.PendingEmail,
+		DeletionScheduledFor: user.DeletionScheduledFor,
+		CreatedAt:            user.CreatedAt,
+		UpdatedAt:            user.UpdatedAt,
 	}
-	updateInterviewer := bson.M{"$set": bson.M{"interviewer_name": newName, "updatedAt": now}}
-	result, err := interviewCollection.UpdateMany(context.Background(), filterInterviewer, updateInterviewer)
-	if err != nil {
-		log.Printf("Error updating denormalized interviewer name for user %s: %v", userID.Hex(), err)
-	} else {
-         log.Printf("Updated %d future interviews for interviewer name change (User: %s)", result.ModifiedCount, userID.Hex())
-    }
+}
 
+// newPeerProfileResponse builds a user's profile as their peers see it,
+// leaving out the fields they chose to hide.
+func newPeerProfileResponse(user *models.User) models.PeerProfileResponse {
+	profile := models.PeerProfileResponse{
+		ID:                user.ID,
+		Name:              user.Name,
+		ProfilePictureURL: user.ProfilePictureURL,
+		AvailableRoles:    user.AvailableRoles,
+		Topics:            user.Topics,
+	}
+	privacy := user.Privacy
+	if !privacy.HideTimezone {
+		profile.Timezone = user.Timezone
+	}
+	if !privacy.HideSkills {
+		profile.Skills = user.Skills
+	}
+	if !privacy.HideExperience {
+		profile.YearsOfExperience = user.YearsOfExperience
+	}
+	if !privacy.HideTargetRoles {
+		profile.TargetRoles = user.TargetRoles
+	}
+	if !privacy.HideTargetCompanies {
+		profile.TargetCompanies = user.TargetCompanies
+	}
+	if !privacy.HideLanguages {
+		profile.Languages = user.Languages
+	}
+	if !privacy.HideBio {
+		profile.Bio = user.Bio
+	}
+	if !privacy.HideLinks {
+		profile.Links = user.Links
+	}
+	return profile
+}
 
-	filterInterviewee := bson.M{
-		"interviewee_id": userID,
-		"scheduled_time": bson.M{"$gt": now},
+// uniqueStrings trims the values and drops empty and repeated ones, keeping
+// the original order.
+func uniqueStrings(values []string) []string {
+	unique := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
 	}
-	updateInterviewee := bson.M{"$set": bson.M{"interviewee_name": newName, "updatedAt": now}}
-	result, err = interviewCollection.UpdateMany(context.Background(), filterInterviewee, updateInterviewee)
-	if err != nil {
-		log.Printf("Error updating denormalized interviewee name for user %s: %v", userID.Hex(), err)
-	} else {
-         log.Printf("Updated %d future interviews for interviewee name change (User: %s)", result.ModifiedCount, userID.Hex())
-    }
+	return unique
 }
 
+const (
+	peerPageDefault = 20
+	peerPageMax     = 100
+	maxPeerQueryLen = 100
+)
 
-// GetPeersHandler retrieves a list of potential peers (other users).
+// GetPeersHandler lists the other users in the caller's tenant, sorted by
+// name. Filters: q (prefix of a word in the name or of the full name, or the
+// full email address), role (interviewer or interviewee), topic (topic ID) and timezone
+// (IANA name). Results are paginated with an opaque cursor: pass the
+// X-Next-Cursor header of one page as ?cursor= to get the next; the header is
+// absent on the last page.
 func GetPeersHandler(c *gin.Context) {
 	userCollection := database.GetCollection("users") // Get collection inside handler
 	requestingUserIDHex, exists := c.Get("userID")
@@ -176,12 +318,76 @@ func GetPeersHandler(c *gin.Context) {
 	}
 	requestingUserOID, _ := primitive.ObjectIDFromHex(requestingUserIDHex.(string)) // Assume valid from middleware
 
-	// Fetch all users *except* the requesting user
-	filter := bson.M{"_id": bson.M{"$ne": requestingUserOID}}
+	// Users blocked either way don't see each other
+	hidden, err := blockedUserIDs(context.Background(), requestingUserOID)
+	if err != nil {
+		log.Printf("Error loading blocks of user %s: %v", requestingUserOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve peers"})
+		return
+	}
+
+	// Fetch users in the same tenant *except* the requesting user
+	filter := tenantUserFilter(tenantID(c))
+	filter["_id"] = bson.M{"$nin": append(hidden, requestingUserOID)}
+	filter["banned"] = bson.M{"$ne": true}
+	filter["deletion_scheduled_for"] = nil
+
+	if q := strings.ToLower(strings.TrimSpace(c.Query("q"))); q != "" {
+		if len(q) > maxPeerQueryLen {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is too long"})
+			return
+		}
+		// Names by prefix: anchored and case-sensitive on lowercased keys, so
+		// the index is used. Emails only in full, so they can't be guessed
+		// a character at a time.
+		filter["$and"] = []bson.M{{"$or": []bson.M{
+			{"search_keys": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(q)}},
+			{"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(q) + "$", Options: "i"}},
+		}}}
+	}
+	if role := c.Query("role"); role != "" {
+		if role != "interviewer" && role != "interviewee" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be interviewer or interviewee"})
+			return
+		}
+		filter["availableRoles"] = role
+	}
+	if topic := c.Query("topic"); topic != "" {
+		filter["topics"] = topic
+	}
+	if timezone := c.Query("timezone"); timezone != "" {
+		// Users who hide their timezone can't be found by it either
+		filter["timezone"] = timezone
+		filter["privacy.hide_timezone"] = bson.M{"$ne": true}
+	}
+
+	limit := int64(peerPageDefault)
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > peerPageMax {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", peerPageMax)})
+			return
+		}
+		limit = int64(n)
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		after, err := decodePeerCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		// Keyset pagination on (name, _id), which is unique and indexed
+		filter["$or"] = []bson.M{
+			{"name": bson.M{"$gt": after.Name}},
+			{"name": after.Name, "_id": bson.M{"$gt": after.ID}},
+		}
+	}
+
 	findOptions := options.Find()
-	// Project only necessary fields for UserInfo DTO
-	findOptions.SetProjection(bson.M{"name": 1, "_id": 1})
-	findOptions.SetSort(bson.M{"name": 1})              // Sort by name
+	// Project only the fields shown in the directory
+	findOptions.SetProjection(bson.M{"name": 1, "_id": 1, "profile_picture_url": 1, "availableRoles": 1, "topics": 1, "timezone": 1, "privacy": 1})
+	findOptions.SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	findOptions.SetLimit(limit + 1) // One extra tells whether there is a next page
 
 	cursor, err := userCollection.Find(context.Background(), filter, findOptions)
 	if err != nil {
@@ -191,22 +397,164 @@ func GetPeersHandler(c *gin.Context) {
 	}
 	defer cursor.Close(context.Background())
 
-	var peers []models.UserInfo // Use UserInfo for minimal data
+	peers := []models.PeerResponse{}
 	if err = cursor.All(context.Background(), &peers); err != nil {
 		log.Printf("Error decoding peers: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process peer data"})
 		return
 	}
 
-	// If no peers found (other than self), return empty list
-	if peers == nil {
-		peers = []models.UserInfo{}
+	for i := range peers {
+		if peers[i].Privacy.HideTimezone {
+			peers[i].Timezone = ""
+		}
+	}
+	if int64(len(peers)) > limit {
+		peers = peers[:limit]
+		last := peers[len(peers)-1]
+		c.Header("X-Next-Cursor", encodePeerCursor(peerCursor{Name: last.Name, ID: last.ID}))
 	}
 
 	log.Printf("Retrieved %d peers for user %s", len(peers), requestingUserIDHex)
 	c.JSON(http.StatusOK, peers)
 }
 
+// GetPeerProfileHandler returns another user's profile as peers see it, with
+// the fields they hide left out. Users outside the caller's tenant are not
+// found. Asking for your own ID previews how others see you.
+func GetPeerProfileHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	peerID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	// Blocked users are hidden here as in the directory
+	blocked, err := isBlocked(context.Background(), userID, peerID)
+	if err != nil {
+		log.Printf("Error checking blocks between %s and %s: %v", userID.Hex(), peerID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile"})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	filter := tenantUserFilter(tenantID(c))
+	filter["_id"] = peerID
+	filter["banned"] = bson.M{"$ne": true}
+	filter["deletion_scheduled_for"] = nil
+
+	var peer models.User
+	if err := userCollection.FindOne(context.Background(), filter).Decode(&peer); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error finding peer profile %s: %v", peerID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPeerProfileResponse(&peer))
+}
+
+// peerCursor is the position after the last peer of a page.
+type peerCursor struct {
+	Name string             `json:"n"`
+	ID   primitive.ObjectID `json:"i"`
+}
+
+func encodePeerCursor(cur peerCursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePeerCursor(s string) (peerCursor, error) {
+	var cur peerCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return cur, err
+	}
+	if cur.ID.IsZero() {
+		return cur, errors.New("cursor has no ID")
+	}
+	return cur, nil
+}
+
+// userSearchKeys returns the lowercased keys a user can be found by prefix in
+// the peer directory: each word of their name and the full name. Emails are
+// left out so peers can't work one out a character at a time; the directory
+// matches them only in full.
+func userSearchKeys(name string) []string {
+	keys := []string{}
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, word := range strings.Fields(name) {
+		add(word)
+	}
+	add(strings.Join(strings.Fields(name), " "))
+	return keys
+}
+
+// updateSearchKeys recomputes a user's search keys after their name changed.
+func updateSearchKeys(ctx context.Context, userID primitive.ObjectID, name string) {
+	_, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"search_keys": userSearchKeys(name)}},
+	)
+	if err != nil {
+		log.Printf("Error updating search keys for user %s: %v", userID.Hex(), err)
+	}
+}
+
+// BackfillUserSearchKeys sets the search keys of users created before the
+// peer directory could be searched, and drops the email from keys stored when
+// it was a prefix key too. It runs at startup and is a no-op once every user
+// has name-only keys.
+func BackfillUserSearchKeys(ctx context.Context) error {
+	cursor, err := database.GetCollection("users").Find(ctx,
+		bson.M{"$or": []bson.M{
+			{"search_keys": bson.M{"$exists": false}},
+			{"$expr": bson.M{"$in": bson.A{bson.M{"$toLower": "$email"}, bson.M{"$ifNull": bson.A{"$search_keys", bson.A{}}}}}},
+		}},
+		options.Find().SetProjection(bson.M{"name": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		updateSearchKeys(ctx, user.ID, user.Name)
+		updated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if updated > 0 {
+		log.Printf("Backfilled search keys for %d users", updated)
+	}
+	return nil
+}
+
 // GetUserStatsHandler retrieves performance stats for an interviewer.
 func GetUserStatsHandler(c *gin.Context) {
 	interviewCollection := database.GetCollection("interviews") // Get collection inside handler
@@ -230,6 +578,7 @@ func GetUserStatsHandler(c *gin.Context) {
 	conductedFilter := bson.M{
 		"interviewer_id": userOID,
 		"status":         "completed",
+		"org_id":         tenantID(c),
 	}
 	conductedCount, err := interviewCollection.CountDocuments(context.Background(), conductedFilter)
 	if err != nil {
@@ -312,8 +661,28 @@ func GetUserStatsHandler(c *gin.Context) {
 }
 
 
-// GetTopicsHandler retrieves a list of available interview topics.
+// GetTopicsHandler retrieves a list of available interview topics. An
+// organization can replace the default list with its own.
 func GetTopicsHandler(c *gin.Context) {
+	if orgID := tenantID(c); orgID != nil {
+		var org models.Organization
+		err := database.GetCollection("organizations").FindOne(context.Background(), bson.M{"_id": *orgID}).Decode(&org)
+		if err != nil {
+			log.Printf("Error finding topics of organization %s: %v", orgID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topics"})
+			return
+		}
+		if len(org.Topics) > 0 {
+			topics := append([]models.Topic(nil), org.Topics...)
+			sort.Slice(topics, func(i, j int) bool {
+				return topics[i].Name < topics[j].Name
+			})
+			log.Printf("Retrieved %d topics of organization %s", len(topics), orgID.Hex())
+			c.JSON(http.StatusOK, topics)
+			return
+		}
+	}
+
 	// In a real app, these might come from a dedicated 'topics' collection
 	// For now, hardcode a list. Ensure IDs are unique if used.
 	topics := []models.Topic{
@@ -369,6 +738,7 @@ func GetAvailabilityHandler(c *gin.Context) {
 			"$lt":  dayEnd,
 		},
 		"status": bson.M{"$ne": "cancelled"}, // Don't consider cancelled interviews as booked
+		"org_id": tenantID(c),
 	}
 	cursor, err := interviewCollection.Find(context.Background(), filter, options.Find().SetProjection(bson.M{"scheduled_time": 1}))
 	if err != nil {
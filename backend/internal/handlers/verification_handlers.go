@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/mailer"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sendVerificationEmail mails the user a signed, single-use link confirming their address.
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := auth.NewActionToken(auth.PurposeEmailVerification, user.ID, config.AppConfig.EmailVerificationTTL, map[string]interface{}{
+		"email": user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.PublicURL + "/api/v1/auth/verify?token=" + url.QueryEscape(token)
+	return mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your Mock Orbit email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not create a Mock Orbit account, you can ignore this email.\n",
+			user.Name, link, config.AppConfig.EmailVerificationTTL),
+	})
+}
+
+// VerifyEmailHandler confirms a user's email address from the link sent at registration.
+func VerifyEmailHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token is required"})
+		return
+	}
+
+	claims, err := auth.ParseActionToken(auth.PurposeEmailVerification, tokenString)
+	if err != nil {
+		log.Printf("Email verification failed: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	userID, err := auth.ClaimObjectID(claims, "user_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	email, _ := claims["email"].(string)
+
+	var user models.User
+	err = userCollection.FindOne(context.Background(), bson.M{"_id": userID, "email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The account is gone or its email changed since the link was sent
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+			return
+		}
+		log.Printf("Error finding user %s for email verification: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if !user.EmailVerificationPending {
+		c.JSON(http.StatusOK, gin.H{"message": "Email address already verified"})
+		return
+	}
+
+	if err := auth.ConsumeActionToken(context.Background(), claims); err != nil {
+		if err == auth.ErrTokenUsed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This verification link has already been used"})
+			return
+		}
+		log.Printf("Error consuming verification token for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_, err = userCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID, "email": email},
+		bson.M{
+			"$set":   bson.M{"email_verified_at": now, "updatedAt": now},
+			"$unset": bson.M{"email_verification_pending": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Error marking email verified for user %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	// Interviews joined through guest links sent to this address become theirs
+	claimGuestHistory(context.Background(), &user)
+
+	log.Printf("Email verified for user %s (%s)", userID.Hex(), email)
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified successfully"})
+}
+
+// ResendVerificationHandler sends a fresh verification link to the current user.
+// Requests are throttled per user by EmailVerificationResendInterval.
+func ResendVerificationHandler(c *gin.Context) {
+	userCollection := database.GetCollection("users")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	interval := config.AppConfig.EmailVerificationResendInterval
+	now := time.Now().UTC()
+
+	// Claim the send slot atomically so concurrent requests cannot both send
+	var user models.User
+	err := userCollection.FindOneAndUpdate(context.Background(),
+		bson.M{
+			"_id":                        userID,
+			"email_verification_pending": true,
+			"$or": []bson.M{
+				{"verification_email_sent_at": nil},
+				{"verification_email_sent_at": bson.M{"$lte": now.Add(-interval)}},
+			},
+		},
+		bson.M{"$set": bson.M{"verification_email_sent_at": now}},
+	).Decode(&user)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error claiming verification resend for user %s: %v", userID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend verification email"})
+			return
+		}
+
+		// Either already verified or throttled; find out which
+		if findErr := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); findErr != nil {
+			log.Printf("Error finding user %s for verification resend: %v", userID.Hex(), findErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend verification email"})
+			return
+		}
+		if !user.EmailVerificationPending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email address already verified"})
+			return
+		}
+		retryAfter := interval
+		if user.VerificationEmailSentAt != nil {
+			retryAfter = time.Until(user.VerificationEmailSentAt.Add(interval))
+		}
+		c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another verification email"})
+		return
+	}
+
+	if err := sendVerificationEmail(context.Background(), &user); err != nil {
+		log.Printf("Error sending verification email to %s: %v", user.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	log.Printf("Resent verification email to %s", user.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
@@ -9,11 +9,10 @@ import (
 	"sync"
 	"time" // Import time package
 
-	"mock-orbit/backend/internal/config"
+	"mock-orbit/backend/internal/auth"
 	"mock-orbit/backend/internal/database" // Import database package
 	"mock-orbit/backend/internal/models"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/bson"         // Import bson package
@@ -55,6 +54,9 @@ type Client struct {
 	Conn        *websocket.Conn
 	InterviewID string
 	UserID      string
+	TokenID     string // jti of the access token the socket was opened with
+	SessionID   string // Session that token belongs to
+	GuestName   string // Set for guests, who join with a guest link instead of a token
 }
 
 // Hub manages WebSocket connections for interview rooms
@@ -230,6 +232,68 @@ func (h *Hub) notifyPeersOfPresenceLocked(joiningClient *Client, isRejoin bool)
 }
 
 
+// DisconnectWhere closes every connection whose client matches the predicate.
+// The read loop of each closed connection removes it from its room.
+func (h *Hub) DisconnectWhere(match func(*Client) bool, reason string) int {
+    h.Mutex.RLock()
+    var toClose []*Client
+    for _, room := range h.Rooms {
+        for _, client := range room {
+            if match(client) {
+                toClose = append(toClose, client)
+            }
+        }
+    }
+    h.Mutex.RUnlock()
+
+    for _, client := range toClose {
+        log.Printf("Closing connection for user %s in room %s: %s", client.UserID, client.InterviewID, reason)
+        // WriteControl is safe to call concurrently with the connection's other writers
+        client.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason), time.Now().Add(time.Second))
+        client.Conn.Close()
+    }
+    return len(toClose)
+}
+
+// CloseRoom closes every connection in a room with a normal closure and
+// removes the room.
+func (h *Hub) CloseRoom(interviewID string, reason string) {
+    h.Mutex.Lock()
+    defer h.Mutex.Unlock()
+    if room, ok := h.Rooms[interviewID]; ok {
+        log.Printf("Force closing all connections in room %s: %s", interviewID, reason)
+        for connToClose, clientToClose := range room {
+            log.Printf("Closing connection for user %s", clientToClose.UserID)
+            connToClose.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+            connToClose.Close()
+        }
+        delete(h.Rooms, interviewID)
+        log.Printf("Room %s removed.", interviewID)
+    }
+}
+
+// UserIDs returns the client IDs currently connected to a room.
+func (h *Hub) UserIDs(interviewID string) map[string]bool {
+    h.Mutex.RLock()
+    defer h.Mutex.RUnlock()
+    ids := make(map[string]bool)
+    for _, client := range h.Rooms[interviewID] {
+        ids[client.UserID] = true
+    }
+    return ids
+}
+
+// disconnectSession closes live sockets opened from the given session.
+func disconnectSession(sessionID string) {
+    hub.DisconnectWhere(func(cl *Client) bool { return cl.SessionID == sessionID }, "Session revoked")
+}
+
+// disconnectUser closes every live socket belonging to the user.
+func disconnectUser(userID string) {
+    hub.DisconnectWhere(func(cl *Client) bool { return cl.UserID == userID }, "Session revoked")
+}
+
+
 // FindPeerConnLocked finds the connection and client object of the other participant(s) in the room.
 // For 1:1 calls, it returns the single peer.
 // Assumes the Hub Mutex is already held (RLock or Lock).
@@ -246,86 +310,80 @@ func (h *Hub) findPeerConnLocked(interviewID string, selfID string) (*websocket.
 
 
 // WebsocketHandler handles WebSocket upgrade requests and manages communication.
+// Clients first get a one-time ticket from POST /interviews/:interviewId/ws-ticket
+// (or its guest counterpart) and connect with ?interviewId=...&ticket=..., so
+// no token ever appears in the socket URL or in access logs.
 func WebsocketHandler(c *gin.Context) {
-	interviewCollection := database.GetCollection("interviews") // Get collection inside handler
 	interviewID := c.Query("interviewId")
-	userID := c.Query("userId") // Get user ID from query param (will be validated against token)
-    token := c.Query("token") // Get token for validation
+	ticketString := c.Query("ticket")
 
-	log.Printf("WebSocket connection attempt: interviewId=%s, userId=%s, token provided=%t", interviewID, userID, token != "")
+	log.Printf("WebSocket connection attempt: interviewId=%s, ticket provided=%t", interviewID, ticketString != "")
 
-	if interviewID == "" || userID == "" || token == "" {
-		log.Println("WebSocket upgrade refused: Missing interviewId, userId, or token")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameters (interviewId, userId, token)"})
+	if interviewID == "" || ticketString == "" {
+		log.Println("WebSocket upgrade refused: Missing interviewId or ticket")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameters (interviewId, ticket)"})
 		return
 	}
 
-    // --- JWT Token Validation ---
-	log.Println("Validating JWT token...")
-    claims := jwt.MapClaims{}
-    parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Printf("Unexpected signing method: %v", token.Header["alg"])
-            return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
-        }
-        return []byte(config.AppConfig.JWTSecret), nil
-    })
+	interviewOID, err := primitive.ObjectIDFromHex(interviewID)
+	if err != nil {
+		log.Printf("WebSocket upgrade refused: Invalid interview ID format '%s'", interviewID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
 
-    if err != nil || !parsedToken.Valid {
-        log.Printf("WebSocket upgrade refused for user %s in room %s: Invalid token: %v", userID, interviewID, err)
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-        return
-    }
-	log.Println("JWT token validated successfully.")
-
-     // --- Validate User ID and Interview Participation ---
-     tokenUserID, okUserID := claims["user_id"].(string)
-     if !okUserID || tokenUserID != userID {
-         log.Printf("WebSocket upgrade refused: User ID '%s' from query does not match token user ID '%s'", userID, tokenUserID)
-         c.JSON(http.StatusForbidden, gin.H{"error": "User ID mismatch"})
-         return
-     }
-	 log.Printf("User ID '%s' matches token.", userID)
-
-    // Validate interview ID format
-	log.Printf("Validating interview ID format: %s", interviewID)
-    interviewOID, err := primitive.ObjectIDFromHex(interviewID)
-    if err != nil {
-        log.Printf("WebSocket upgrade refused: Invalid interview ID format '%s'", interviewID)
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
-        return
-    }
-	log.Printf("Interview ID format validated: %s", interviewOID.Hex())
-    userOID, _ := primitive.ObjectIDFromHex(tokenUserID)
-
-    // Check if user is part of the specified interview
-	log.Printf("Checking participation for user %s in interview %s", userOID.Hex(), interviewOID.Hex())
-    count, err := interviewCollection.CountDocuments(context.Background(), bson.M{
-        "_id": interviewOID,
-        "$or": []bson.M{
-            {"interviewer_id": userOID},
-            {"interviewee_id": userOID},
-        },
-        "status": bson.M{"$in": []string{"scheduled", "in_progress"}}, // Allow joining scheduled or in-progress
-    })
-    if err != nil {
-        log.Printf("Error checking interview participation for user %s in interview %s: %v", tokenUserID, interviewID, err)
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify interview participation"})
-        return
-    }
-    if count == 0 {
-        // Fetch interview to check if it's completed/cancelled to give specific error
-        var interview models.Interview
-        findErr := interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID}).Decode(&interview)
-        errMsg := "Not authorized for this interview or interview is not active"
-        if findErr == nil && (interview.Status == "completed" || interview.Status == "cancelled") {
-            errMsg = "This interview has already ended or been cancelled."
-        }
-        log.Printf("WebSocket upgrade refused: User %s in interview %s. Reason: %s", tokenUserID, interviewID, errMsg)
-        c.JSON(http.StatusForbidden, gin.H{"error": errMsg})
-        return
-    }
-	log.Printf("User %s authorized for interview %s.", userID, interviewID)
+	// Participation was checked when the ticket was issued, moments ago
+	ticket, err := auth.RedeemWSTicket(context.Background(), ticketString, interviewOID)
+	if err != nil {
+		if err == auth.ErrInvalidToken {
+			log.Printf("WebSocket upgrade refused for room %s: invalid, expired or reused ticket", interviewID)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			return
+		}
+		log.Printf("Error redeeming WebSocket ticket for room %s: %v", interviewID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ticket"})
+		return
+	}
+
+	if ticket.GuestID != nil {
+		log.Printf("Guest %s authorized for interview %s.", ticket.GuestID.Hex(), interviewID)
+		recordGuestJoined(context.Background(), interviewOID, *ticket.GuestID)
+		serveInterviewRoom(c, interviewOID, &Client{
+			InterviewID: interviewID,
+			UserID:      guestClientID(*ticket.GuestID),
+			GuestName:   ticket.GuestName,
+		})
+		return
+	}
+
+	// A logout between issuing and redeeming the ticket still counts
+	if _, err := auth.ActiveSession(context.Background(), *ticket.SessionID, *ticket.UserID); err != nil {
+		log.Printf("WebSocket upgrade refused for user %s in room %s: %v", ticket.UserID.Hex(), interviewID, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+		return
+	}
+	// A block placed since the ticket was issued also counts
+	if blocked, err := roomBlocked(context.Background(), interviewOID, *ticket.UserID); err != nil || blocked {
+		log.Printf("WebSocket upgrade refused for user %s in room %s: blocked=%t err=%v", ticket.UserID.Hex(), interviewID, blocked, err)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't join a room with this user"})
+		return
+	}
+	log.Printf("User %s authorized for interview %s.", ticket.UserID.Hex(), interviewID)
+
+	serveInterviewRoom(c, interviewOID, &Client{
+		InterviewID: interviewID,
+		UserID:      ticket.UserID.Hex(),
+		TokenID:     ticket.TokenID,
+		SessionID:   ticket.SessionID.Hex(),
+	})
+}
+
+// serveInterviewRoom upgrades an authorized request and relays the client's
+// messages to the rest of the room until the connection closes.
+func serveInterviewRoom(c *gin.Context, interviewOID primitive.ObjectID, client *Client) {
+	interviewCollection := database.GetCollection("interviews")
+	interviewID := client.InterviewID
+	userID := client.UserID
 
 	// Upgrade HTTP connection to WebSocket
 	log.Printf("Attempting to upgrade connection to WebSocket for user %s...", userID)
@@ -337,12 +395,10 @@ func WebsocketHandler(c *gin.Context) {
 	log.Printf("WebSocket connection upgraded successfully for user %s.", userID)
 	defer conn.Close() // Ensure connection is closed when handler exits
 
-	client := &Client{
-		Conn:        conn,
-		InterviewID: interviewID,
-		UserID:      userID,
-	}
+	client.Conn = conn
 	hub.AddClient(client) // AddClient now handles rejoin logic notifications
+	// Added first so that of two participants joining together, at least one sees the other
+	startInterviewOnJoin(context.Background(), interviewOID, client)
     defer func() {
         log.Printf("Removing client %s from room %s due to connection close or error in read loop exit", client.UserID, client.InterviewID)
         hub.RemoveClient(client)
@@ -384,6 +440,7 @@ func WebsocketHandler(c *gin.Context) {
              chatMsg.InterviewID = interviewID
              chatMsg.SenderID = userID
              chatMsg.Timestamp = time.Now().UnixMilli()
+             if client.GuestName != "" { chatMsg.SenderName = client.GuestName + " (guest)" }
              if chatMsg.SenderName == "" { chatMsg.SenderName = "User_" + userID[:4] }
              // TODO: Store chat message in DB?
              hub.BroadcastMessage(interviewID, conn, map[string]interface{}{"type": "chat-message", "message": chatMsg})
@@ -473,33 +530,28 @@ func WebsocketHandler(c *gin.Context) {
             }
 
          case "end-interview":
+            if client.GuestName != "" {
+                hub.SendMessageTo(conn, map[string]interface{}{"type": "error", "message": "Guests cannot end the interview"})
+                continue
+            }
             log.Printf("User %s initiated 'end-interview' for room %s", client.UserID, interviewID)
-            _, err := interviewCollection.UpdateOne(
-                 context.Background(),
-                 bson.M{"_id": interviewOID},
-                 bson.M{"$set": bson.M{"status": "completed", "updatedAt": time.Now().UTC()}},
-            )
-            if err != nil { log.Printf("Error updating interview status to completed for %s: %v", interviewID, err) }
-
-             hub.Mutex.RLock()
-             hub.broadcastMessageLocked(interviewID, nil, map[string]interface{}{"type": "interview-ended"})
-             hub.Mutex.RUnlock()
-
-             // Goroutine to close connections and clean up room
-             go func(roomID string) {
-                 hub.Mutex.Lock()
-                 defer hub.Mutex.Unlock()
-                 if room, ok := hub.Rooms[roomID]; ok {
-                     log.Printf("Force closing all connections in room %s due to 'end-interview'", roomID)
-                     for connToClose, clientToClose := range room {
-                         log.Printf("Closing connection for user %s", clientToClose.UserID)
-                         connToClose.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Interview ended"))
-                         connToClose.Close()
-                     }
-                     delete(hub.Rooms, roomID)
-                     log.Printf("Room %s removed after 'end-interview'.", roomID)
-                 }
-             }(interviewID)
+            // Same as POST /interviews/:interviewId/complete, which also tells the room and closes it
+            userOID, _ := primitive.ObjectIDFromHex(userID)
+            var interview models.Interview
+            err := interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID}).Decode(&interview)
+            if err == nil {
+                _, err = changeInterviewStatus(context.Background(), &interview, "complete", userOID, participantRole(&interview, userOID), "")
+            }
+            switch err {
+            case nil:
+            case errTransitionNotAllowed:
+                hub.SendMessageTo(conn, map[string]interface{}{"type": "error", "message": "You can't end this interview"})
+            case errInvalidTransition:
+                hub.SendMessageTo(conn, map[string]interface{}{"type": "error", "message": "The interview is " + interview.Status + " and can't be ended"})
+            default:
+                log.Printf("Error completing interview %s: %v", interviewID, err)
+                hub.SendMessageTo(conn, map[string]interface{}{"type": "error", "message": "Failed to end the interview"})
+            }
 
 		default:
 			log.Printf("Unknown message type received from %s: %s", client.UserID, msgType)
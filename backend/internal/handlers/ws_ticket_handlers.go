@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"mock-orbit/backend/internal/auth"
+	"mock-orbit/backend/internal/database"
+	"mock-orbit/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateWSTicketHandler issues a one-time ticket for joining the interview room
+// over WebSocket. The caller must be a participant and the interview must be
+// scheduled or in progress.
+func CreateWSTicketHandler(c *gin.Context) {
+	interviewCollection := database.GetCollection("interviews")
+	userID := c.MustGet("userObjectID").(primitive.ObjectID)
+	sessionID := c.MustGet("sessionID").(primitive.ObjectID)
+
+	interviewOID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+
+	count, err := interviewCollection.CountDocuments(context.Background(), bson.M{
+		"_id": interviewOID,
+		"$or": []bson.M{
+			{"interviewer_id": userID},
+			{"interviewee_id": userID},
+		},
+		"status": bson.M{"$in": []string{"scheduled", "in_progress"}}, // Allow joining scheduled or in-progress
+		"org_id": tenantID(c),
+	})
+	if err != nil {
+		log.Printf("Error checking interview participation for user %s in interview %s: %v", userID.Hex(), interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify interview participation"})
+		return
+	}
+	if count == 0 {
+		// Fetch interview to check if it's completed/cancelled to give specific error
+		var interview models.Interview
+		findErr := interviewCollection.FindOne(context.Background(), bson.M{"_id": interviewOID, "org_id": tenantID(c)}).Decode(&interview)
+		errMsg := "Not authorized for this interview or interview is not active"
+		if findErr == nil && interview.Status != "scheduled" && interview.Status != "in_progress" {
+			errMsg = "This interview has already ended or been cancelled."
+		}
+		log.Printf("WebSocket ticket refused: User %s in interview %s. Reason: %s", userID.Hex(), interviewOID.Hex(), errMsg)
+		c.JSON(http.StatusForbidden, gin.H{"error": errMsg})
+		return
+	}
+
+	blocked, err := roomBlocked(context.Background(), interviewOID, userID)
+	if err != nil {
+		log.Printf("Error checking blocks for user %s in interview %s: %v", userID.Hex(), interviewOID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify interview participation"})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't join a room with this user", "code": "user_blocked"})
+		return
+	}
+
+	issueWSTicket(c, models.WSTicket{
+		InterviewID: interviewOID,
+		UserID:      &userID,
+		SessionID:   &sessionID,
+		TokenID:     c.GetString("tokenID"),
+	}, nil)
+}
+
+// CreateGuestWSTicketHandler is CreateWSTicketHandler for guests, who prove
+// their invitation with the token from their guest link.
+func CreateGuestWSTicketHandler(c *gin.Context) {
+	interviewOID, err := primitive.ObjectIDFromHex(c.Param("interviewId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID format"})
+		return
+	}
+	var input models.GuestTicketInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data", "details": err.Error()})
+		return
+	}
+
+	interview, guest, err := authenticateGuest(context.Background(), input.Token, interviewOID)
+	if err != nil {
+		log.Printf("WebSocket ticket refused for guest in interview %s: %v", interviewOID.Hex(), err)
+		switch err {
+		case errGuestNotAllowed:
+			c.JSON(http.StatusForbidden, gin.H{"error": "This guest link is no longer valid for this interview"})
+		case auth.ErrInvalidToken, auth.ErrExpiredToken:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired guest link"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guest link"})
+		}
+		return
+	}
+
+	// Guests can't load the interview, so the room gets what it shows from here
+	clientID := guestClientID(guest.ID)
+	interviewee := gin.H{"id": interview.IntervieweeID, "name": interview.IntervieweeName}
+	if guest.Interviewee {
+		interviewee = gin.H{"id": clientID, "name": guest.Name}
+	}
+	issueWSTicket(c, models.WSTicket{
+		InterviewID: interviewOID,
+		GuestID:     &guest.ID,
+		GuestName:   guest.Name,
+	}, gin.H{
+		"client_id": clientID,
+		"interview": gin.H{
+			"id":          interview.ID,
+			"topic":       interview.Topic,
+			"status":      interview.Status,
+			"interviewer": models.UserInfo{ID: interview.InterviewerID, Name: interview.InterviewerName},
+			"interviewee": interviewee,
+		},
+	})
+}
+
+// roomBlocked reports whether the user and the other participant of the
+// interview have blocked each other, either way.
+func roomBlocked(ctx context.Context, interviewID, userID primitive.ObjectID) (bool, error) {
+	var interview models.Interview
+	err := database.GetCollection("interviews").FindOne(ctx, bson.M{"_id": interviewID},
+		options.FindOne().SetProjection(bson.M{"interviewer_id": 1, "interviewee_id": 1}),
+	).Decode(&interview)
+	if err != nil {
+		return false, err
+	}
+	other := interview.IntervieweeID
+	if other == userID {
+		other = interview.InterviewerID
+	}
+	// Guest interviewee slots have no account to block
+	if other.IsZero() || other == userID {
+		return false, nil
+	}
+	return isBlocked(ctx, userID, other)
+}
+
+// issueWSTicket responds with a new ticket, plus any extra fields.
+func issueWSTicket(c *gin.Context, ticket models.WSTicket, extra gin.H) {
+	raw, expiresAt, err := auth.IssueWSTicket(context.Background(), ticket)
+	if err != nil {
+		log.Printf("Error issuing WebSocket ticket for interview %s: %v", ticket.InterviewID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response := gin.H{
+		"ticket":     raw,
+		"expires_at": expiresAt,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	c.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,108 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// exifTIFF builds a TIFF header with one IFD holding an orientation tag.
+func exifTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8) // First IFD right after the header
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)      // One value
+	order.PutUint16(tiff[18:], orientation)
+	return tiff
+}
+
+// jpegWith builds the start of a JPEG file with the given segments, each a
+// marker followed by its payload.
+func jpegWith(segments ...[]byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, segment := range segments {
+		length := make([]byte, 2)
+		binary.BigEndian.PutUint16(length, uint16(len(segment)+1)) // Payload plus the length field itself
+		data = append(data, 0xFF, segment[0])
+		data = append(data, length...)
+		data = append(data, segment[1:]...)
+	}
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func app1(payload []byte) []byte {
+	return append([]byte{0xE1}, payload...)
+}
+
+func exif(tiff []byte) []byte {
+	return app1(append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := 1; orientation <= 8; orientation++ {
+			data := jpegWith(exif(exifTIFF(order, uint16(orientation))))
+			if got := jpegOrientation(data); got != orientation {
+				t.Errorf("%s orientation %d: got %d", order, orientation, got)
+			}
+		}
+	}
+
+	valid := exifTIFF(binary.BigEndian, 6)
+	badOffset := exifTIFF(binary.BigEndian, 6)
+	binary.BigEndian.PutUint32(badOffset[4:], 1<<31)
+	shortOffset := exifTIFF(binary.BigEndian, 6)
+	binary.BigEndian.PutUint32(shortOffset[4:], 4) // Points into the header
+	tooManyEntries := exifTIFF(binary.BigEndian, 6)
+	binary.BigEndian.PutUint16(tooManyEntries[8:], 1000)
+	binary.BigEndian.PutUint16(tooManyEntries[10:], 0x0100) // ImageWidth; no orientation before the end
+	wrongType := exifTIFF(binary.BigEndian, 6)
+	binary.BigEndian.PutUint16(wrongType[12:], 4) // LONG
+	outOfRange := exifTIFF(binary.LittleEndian, 9)
+	badMagic := exifTIFF(binary.LittleEndian, 6)
+	binary.LittleEndian.PutUint16(badMagic[2:], 43)
+	badOrder := exifTIFF(binary.LittleEndian, 6)
+	copy(badOrder, "XX")
+
+	withEXIF := jpegWith(exif(valid))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"empty", nil, 1},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 1},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00"), 1},
+		{"JPEG without EXIF", jpegWith([]byte{0xE0, 'J', 'F', 'I', 'F', 0}), 1},
+		{"EXIF after other segments", jpegWith([]byte{0xE0, 'J', 'F', 'I', 'F', 0}, exif(valid)), 6},
+		{"fill bytes before a marker", append([]byte{0xFF, 0xD8, 0xFF}, withEXIF[2:]...), 6},
+		{"EXIF after the image data", append(jpegWith(), withEXIF[2:]...), 1},
+		{"APP1 that isn't EXIF", jpegWith(app1([]byte("http://ns.adobe.com/xap/1.0/\x00"))), 1},
+		{"truncated APP1", withEXIF[:20], 1},
+		{"APP1 longer than the file", withEXIF[:len(withEXIF)-8], 1},
+		{"segment length below 2", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0x00, 0x00}, 1},
+		{"garbage after SOI", []byte{0xFF, 0xD8, 0x12, 0x34, 0x56, 0x78}, 1},
+		{"truncated TIFF header", jpegWith(exif(valid[:6])), 1},
+		{"IFD offset past the end", jpegWith(exif(badOffset)), 1},
+		{"IFD offset inside the header", jpegWith(exif(shortOffset)), 1},
+		{"entry count past the end", jpegWith(exif(tooManyEntries)), 1},
+		{"orientation with the wrong type", jpegWith(exif(wrongType)), 1},
+		{"orientation out of range", jpegWith(exif(outOfRange)), 1},
+		{"bad TIFF magic", jpegWith(exif(badMagic)), 1},
+		{"bad byte order", jpegWith(exif(badOrder)), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package mailer sends transactional email (verification links, password
+// resets, notices) through a pluggable Mailer.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	current Mailer = &LogMailer{}
+	mu      sync.RWMutex
+
+	errHeaderInjection = errors.New("mailer: header values must not contain line breaks")
+)
+
+// Setup selects the Mailer implementation from configuration.
+func Setup() {
+	cfg := config.AppConfig
+	switch cfg.MailDriver {
+	case "smtp":
+		Set(&SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+		log.Printf("Mailer: using SMTP server %s:%s", cfg.SMTPHost, cfg.SMTPPort)
+	default:
+		Set(&LogMailer{Dir: cfg.MailLogDir})
+		if cfg.MailLogDir != "" {
+			log.Printf("Mailer: writing messages to %s", cfg.MailLogDir)
+		} else {
+			log.Println("Mailer: logging messages (no mail will be delivered)")
+		}
+	}
+}
+
+// Set replaces the active Mailer, e.g. with a sink in tests.
+func Set(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = m
+}
+
+// Send delivers a message through the active Mailer.
+func Send(ctx context.Context, msg Message) error {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	return m.Send(ctx, msg)
+}
+
+// LogMailer is a development sink. It logs every message and, when Dir is set,
+// also writes it to an .eml file there.
+type LogMailer struct {
+	Dir string
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	if m.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFilename(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(config.AppConfig.MailFrom, msg), 0o644)
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// buildMessage renders an RFC 5322 message with a plain-text body.
+func buildMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
\ No newline at end of file
@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mock-orbit/backend/internal/config"
+)
+
+type recordingMailer struct {
+	sent []Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestBuildMessage(t *testing.T) {
+	raw := string(buildMessage("Mock Orbit <no-reply@test>", Message{
+		To:      "ada@example.com",
+		Subject: "Hello",
+		Body:    "Line one\nLine two\n",
+	}))
+
+	header, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no blank line between header and body in %q", raw)
+	}
+	for _, want := range []string{
+		"From: Mock Orbit <no-reply@test>",
+		"To: ada@example.com",
+		"Subject: Hello",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	} {
+		if !strings.Contains(header+"\r\n", want+"\r\n") {
+			t.Errorf("header is missing %q:\n%s", want, header)
+		}
+	}
+	if !strings.Contains(header, "\r\nDate: ") {
+		t.Errorf("header is missing Date:\n%s", header)
+	}
+	if body != "Line one\r\nLine two\r\n" {
+		t.Errorf("body = %q, want CRLF line endings", body)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	// No server is listening; a message that got past the check would fail to dial instead
+	m := &SMTPMailer{Host: "127.0.0.1", Port: "1", From: "no-reply@test"}
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"CRLF in recipient", Message{To: "ada@example.com\r\nBcc: eve@example.com", Subject: "Hi"}},
+		{"LF in recipient", Message{To: "ada@example.com\nBcc: eve@example.com", Subject: "Hi"}},
+		{"CR in subject", Message{To: "ada@example.com", Subject: "Hi\rBcc: eve@example.com"}},
+		{"LF in subject", Message{To: "ada@example.com", Subject: "Hi\nBcc: eve@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Send(context.Background(), tt.msg); err != errHeaderInjection {
+				t.Errorf("Send() = %v, want errHeaderInjection", err)
+			}
+		})
+	}
+}
+
+func TestLogMailerWritesMessageFile(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{MailFrom: "no-reply@test"}
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	dir := t.TempDir()
+	m := &LogMailer{Dir: dir}
+	if err := m.Send(context.Background(), Message{To: "a/b@example.com", Subject: "Hi", Body: "Body"}); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("found %v (%v), want one .eml file", files, err)
+	}
+	if !strings.HasSuffix(files[0], "-a_b@example.com.eml") {
+		t.Errorf("file name %s does not contain the sanitized recipient", filepath.Base(files[0]))
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "From: no-reply@test\r\n") || !strings.HasSuffix(string(content), "\r\n\r\nBody") {
+		t.Errorf("unexpected message:\n%s", content)
+	}
+}
+
+func TestSendUsesActiveMailer(t *testing.T) {
+	recorder := &recordingMailer{}
+	Set(recorder)
+	t.Cleanup(func() { Set(&LogMailer{}) })
+
+	msg := Message{To: "ada@example.com", Subject: "Hi", Body: "Body"}
+	if err := Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if len(recorder.sent) != 1 || recorder.sent[0] != msg {
+		t.Errorf("active mailer got %v, want %v", recorder.sent, msg)
+	}
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPMailer delivers mail through an SMTP relay, authenticating with PLAIN
+// auth when a username is configured.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errHeaderInjection
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, buildMessage(m.From, msg))
+}
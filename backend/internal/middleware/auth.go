@@ -6,17 +6,25 @@ import (
 	"net/http"
 	"strings"
 
-	"mock-orbit/backend/internal/config"
-	"mock-orbit/backend/internal/database"
-	"mock-orbit/backend/internal/models"
+	"mock-orbit/backend/internal/auth"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// AuthMiddleware authenticates a user session (JWT access token). Personal
+// access tokens are rejected: routes that accept them use ScopedAuthMiddleware.
 func AuthMiddleware() gin.HandlerFunc {
+	return authenticate("")
+}
+
+// ScopedAuthMiddleware authenticates a session or a personal access token. A
+// PAT must hold resource:read for GET and HEAD requests and resource:write
+// for anything else.
+func ScopedAuthMiddleware(resource string) gin.HandlerFunc {
+	return authenticate(resource)
+}
+
+func authenticate(resource string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,108 +38,142 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the alg is what you expect:
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
-			}
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
+		isPAT := auth.IsPersonalAccessToken(tokenString)
+		if isPAT && resource == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Personal access tokens cannot be used for this endpoint"})
+			return
+		}
 
+		var principal *auth.Principal
+		var err error
+		if isPAT {
+			principal, err = auth.AuthenticatePAT(context.Background(), tokenString)
+		} else {
+			principal, err = auth.Authenticate(context.Background(), tokenString)
+		}
 		if err != nil {
-			log.Printf("Token parsing error: %v", err)
-			status := http.StatusUnauthorized
-			errMsg := "Invalid or expired token"
-			if ve, ok := err.(*jwt.ValidationError); ok {
-				if ve.Errors&jwt.ValidationErrorExpired != 0 {
-					errMsg = "Token has expired"
-				}
+			log.Printf("Token authentication error: %v", err)
+			switch err {
+			case auth.ErrExpiredToken:
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has expired"})
+			case auth.ErrRevokedToken:
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			case auth.ErrUserNotFound:
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User associated with token not found"})
+			case auth.ErrUserBanned:
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This account has been suspended", "code": "account_banned"})
+			case auth.ErrInvalidToken:
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
 			}
-			c.AbortWithStatusJSON(status, gin.H{"error": errMsg})
 			return
 		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userIDStr, ok := claims["user_id"].(string)
-			if !ok {
-				log.Println("user_id claim missing or not a string")
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-				return
+		user := principal.User
+		userID := user.ID
+		userIDStr := userID.Hex()
+
+		if isPAT {
+			level := "write"
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				level = "read"
 			}
-
-			userID, err := primitive.ObjectIDFromHex(userIDStr)
-			if err != nil {
-				log.Printf("Invalid user ID format in token: %v", err)
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+			if !auth.HasScope(principal.Scopes, resource, level) {
+				log.Printf("Access Denied: Personal access token %s of user %s lacks scope %s:%s", principal.TokenID, userIDStr, resource, level)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token lacks the required scope", "required_scope": resource + ":" + level})
 				return
 			}
+		}
 
-			// Optional: Fetch user from DB to ensure they still exist/aren't banned
-			var user models.User
-			userCollection := database.GetCollection("users")
-			err = userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user)
-			if err != nil {
-				log.Printf("User not found for token ID %s: %v", userIDStr, err)
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User associated with token not found"})
-				return
-			}
+		// Set user information in the context
+		c.Set("userID", userIDStr) // Store as string for easier use
+		c.Set("userObjectID", userID) // Store ObjectID if needed
+		c.Set("userRoles", user.AvailableRoles) // Store available roles
+		c.Set("tokenID", principal.TokenID) // jti (or PAT ID), used for logout/revocation
+		c.Set("tokenExpiresAt", principal.ExpiresAt)
+		c.Set("emailVerified", !user.EmailVerificationPending)
+		c.Set("activeRole", principal.ActiveRole) // Role the caller is acting in
+		c.Set("orgID", principal.OrgID) // Tenant the caller is acting in (*primitive.ObjectID, nil for the public space)
+		if isPAT {
+			c.Set("tokenScopes", principal.Scopes)
+		} else {
+			c.Set("sessionID", principal.SessionID)
+		}
 
-			// Set user information in the context
-			c.Set("userID", userIDStr) // Store as string for easier use
-			c.Set("userObjectID", userID) // Store ObjectID if needed
-			c.Set("userRoles", user.AvailableRoles) // Store available roles
+		log.Printf("Authenticated user: %s, Roles: %v, Active role: %s", userIDStr, user.AvailableRoles, principal.ActiveRole)
+		c.Next()
+	}
+}
 
-			log.Printf("Authenticated user: %s, Roles: %v", userIDStr, user.AvailableRoles)
-			c.Next()
-		} else {
-			log.Println("Token claims invalid or token not valid")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// RequireVerifiedEmail restricts a route to users who have confirmed their email
+// address. Unverified users can still log in, view and edit their profile and
+// request a new verification link.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("emailVerified") {
+			userID, _ := c.Get("userID")
+			log.Printf("Access Denied: User %s has not verified their email address", userID)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Please verify your email address to use this feature.", "code": "email_not_verified"})
+			return
 		}
+		c.Next()
 	}
 }
 
-// RoleMiddleware checks if the user has the required role(s).
-// The active role is not directly enforced here, as the frontend controls UI.
-// This checks if the user *can* perform actions related to the required role.
-func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
+// AdminMiddleware restricts a route to users with the "admin" role. Admin is a
+// privilege rather than something a user acts as, so it is checked against
+// the user's roles instead of their active role.
+func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRolesVal, exists := c.Get("userRoles")
 		if !exists {
-			log.Println("RoleMiddleware: userRoles not found in context (AuthMiddleware likely missing or failed)")
+			log.Println("AdminMiddleware: userRoles not found in context (AuthMiddleware likely missing or failed)")
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied. User roles not determined."})
 			return
 		}
 
 		userRoles, ok := userRolesVal.([]string)
 		if !ok {
-			log.Println("RoleMiddleware: userRoles in context is not a []string")
+			log.Println("AdminMiddleware: userRoles in context is not a []string")
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error processing user roles."})
 			return
 		}
 
-		hasRequiredRole := false
-		for _, reqRole := range requiredRoles {
-			for _, userRole := range userRoles {
-				if userRole == reqRole {
-					hasRequiredRole = true
-					break
-				}
-			}
-			if hasRequiredRole {
-				break
+		for _, role := range userRoles {
+			if role == "admin" {
+				c.Next()
+				return
 			}
 		}
 
-		if !hasRequiredRole {
-			userID, _ := c.Get("userID")
-			log.Printf("Access Denied: User %s lacks required roles (%v). Has roles: %v", userID, requiredRoles, userRoles)
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied. Insufficient privileges."})
-			return
-		}
+		userID, _ := c.Get("userID")
+		log.Printf("Access Denied: User %s is not an admin. Has roles: %v", userID, userRoles)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied. Insufficient privileges."})
+	}
+}
 
-		// Log which roles were required and that the user passed
+// RoleMiddleware checks that the user is acting in one of the required roles.
+// The active role comes from the access token and is switched with
+// POST /users/active-role; holding a role without acting in it is not enough.
+func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		activeRole := c.GetString("activeRole")
 		userID, _ := c.Get("userID")
-		log.Printf("Access Granted: User %s has required roles (%v).", userID, requiredRoles)
-		c.Next()
+
+		for _, reqRole := range requiredRoles {
+			if activeRole != "" && activeRole == reqRole {
+				log.Printf("Access Granted: User %s is acting as %s (required %v).", userID, activeRole, requiredRoles)
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("Access Denied: User %s is acting as %q, required one of %v", userID, activeRole, requiredRoles)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":          "Access denied. Switch your active role to use this feature.",
+			"code":           "active_role_required",
+			"required_roles": requiredRoles,
+			"active_role":    activeRole,
+		})
 	}
 }
@@ -15,10 +15,163 @@ type User struct {
 	Role              string             `bson:"role" json:"role"` // Primary role at signup
 	AvailableRoles    []string           `bson:"availableRoles" json:"availableRoles"` // All roles user can have
 	ProfilePictureURL *string            `bson:"profile_picture_url,omitempty" json:"profile_picture_url,omitempty"`
+	Avatar            *Avatar            `bson:"avatar,omitempty" json:"-"`
+	TokenVersion      int                `bson:"token_version" json:"-"` // Bumped to invalidate all issued access tokens
+	// Set at registration until the address is confirmed. Accounts created before
+	// verification existed do not have the field and are treated as verified.
+	EmailVerificationPending bool       `bson:"email_verification_pending,omitempty" json:"-"`
+	EmailVerifiedAt          *time.Time `bson:"email_verified_at,omitempty" json:"-"`
+	VerificationEmailSentAt  *time.Time `bson:"verification_email_sent_at,omitempty" json:"-"`
+	PasswordResetSentAt      *time.Time `bson:"password_reset_sent_at,omitempty" json:"-"`
+	// Address the user asked to change to, until they confirm it from that inbox
+	PendingEmail            string     `bson:"pending_email,omitempty" json:"-"`
+	PendingEmailRequestedAt *time.Time `bson:"pending_email_requested_at,omitempty" json:"-"`
+	// TOTP two-factor authentication. The pending secret is held between
+	// enrollment and confirmation; recovery codes are stored as SHA-256 hashes.
+	TOTPEnabled        bool     `bson:"totp_enabled,omitempty" json:"-"`
+	TOTPSecret         string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret  string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastUsedStep   int64    `bson:"totp_last_used_step,omitempty" json:"-"` // Rejects replay of an accepted code
+	RecoveryCodeHashes []string `bson:"recovery_codes,omitempty" json:"-"`
+	// External OpenID Connect identities linked to this account
+	Identities []ExternalIdentity `bson:"identities,omitempty" json:"-"`
+	// Organizations the user belongs to. Users without memberships only see
+	// the public space.
+	Memberships []OrgMembership `bson:"memberships,omitempty" json:"-"`
+	// Lowercased name words, full name and email, maintained on every change
+	// to them. Backs prefix search in the peer directory.
+	SearchKeys []string `bson:"search_keys,omitempty" json:"-"`
+	// Set together with a new name, until interviews show it
+	NameSync *NameSyncJob `bson:"name_sync,omitempty" json:"-"`
+	// Bumped by transactions that check the user's schedule, so that two of
+	// them booking the same user at once conflict instead of double-booking
+	ScheduleVersion int64 `bson:"schedule_version,omitempty" json:"-"`
+	// Shown in the peer directory and used to filter it
+	Timezone string   `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"
+	Topics   []string `bson:"topics,omitempty" json:"topics,omitempty"`     // IDs of topics the user has expertise in
+	// Profile shown to practice partners, minus the fields hidden by Privacy
+	Skills            []Skill        `bson:"skills,omitempty" json:"skills,omitempty"`
+	YearsOfExperience *int           `bson:"years_of_experience,omitempty" json:"yearsOfExperience,omitempty"`
+	TargetRoles       []string       `bson:"target_roles,omitempty" json:"targetRoles,omitempty"`         // e.g. "Backend Engineer"
+	TargetCompanies   []string       `bson:"target_companies,omitempty" json:"targetCompanies,omitempty"` // Companies the user is preparing for
+	Languages         []string       `bson:"languages,omitempty" json:"languages,omitempty"`              // BCP 47 tags, e.g. "en", "pt-BR"
+	Bio               string         `bson:"bio,omitempty" json:"bio,omitempty"`
+	Links             []ProfileLink  `bson:"links,omitempty" json:"links,omitempty"`
+	Privacy           ProfilePrivacy `bson:"privacy,omitempty" json:"privacy"`
+	// Set by an admin. Banned users cannot log in and their tokens stop working.
+	Banned       bool                `bson:"banned,omitempty" json:"-"`
+	BannedAt     *time.Time          `bson:"banned_at,omitempty" json:"-"`
+	BannedBy     *primitive.ObjectID `bson:"banned_by,omitempty" json:"-"`
+	BannedReason string              `bson:"banned_reason,omitempty" json:"-"`
+	// Set while a requested account deletion is in its grace period. The
+	// account is purged once DeletionScheduledFor passes unless cancelled.
+	DeletionRequestedAt  *time.Time `bson:"deletion_requested_at,omitempty" json:"-"`
+	DeletionScheduledFor *time.Time `bson:"deletion_scheduled_for,omitempty" json:"-"`
 	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
 
+// Avatar is a profile picture uploaded to our blob store. Every upload gets a
+// new Version, so its URLs can be cached forever.
+type Avatar struct {
+	Version    string    `bson:"version"`
+	Format     string    `bson:"format"` // File extension of the variants: "jpg" or "png"
+	UploadedAt time.Time `bson:"uploaded_at"`
+}
+
+// Skill is a skill listed on a user's profile with a self-assessed level.
+type Skill struct {
+	Name  string `bson:"name" json:"name" binding:"required,max=50"`
+	Level string `bson:"level" json:"level" binding:"required,oneof=beginner intermediate advanced expert"`
+}
+
+// ProfileLink is a link shown on a user's profile (GitHub, LinkedIn, a blog...).
+type ProfileLink struct {
+	Label string `bson:"label,omitempty" json:"label,omitempty" binding:"omitempty,max=50"`
+	URL   string `bson:"url" json:"url" binding:"required,http_url,max=300"`
+}
+
+// ProfilePrivacy lists the profile fields a user hides from peers. Everything
+// is visible by default; the user always sees their own profile in full.
+type ProfilePrivacy struct {
+	HideSkills          bool `bson:"hide_skills,omitempty" json:"hideSkills"`
+	HideExperience      bool `bson:"hide_experience,omitempty" json:"hideExperience"`
+	HideTargetRoles     bool `bson:"hide_target_roles,omitempty" json:"hideTargetRoles"`
+	HideTargetCompanies bool `bson:"hide_target_companies,omitempty" json:"hideTargetCompanies"`
+	HideTimezone        bool `bson:"hide_timezone,omitempty" json:"hideTimezone"`
+	HideLanguages       bool `bson:"hide_languages,omitempty" json:"hideLanguages"`
+	HideBio             bool `bson:"hide_bio,omitempty" json:"hideBio"`
+	HideLinks           bool `bson:"hide_links,omitempty" json:"hideLinks"`
+}
+
+// NameSyncJob is a pending copy of a user's name into the interviews that
+// show it. It is stored on the user in the same write as the rename, so it
+// survives failures and restarts, and is removed once done.
+type NameSyncJob struct {
+	Name          string    `bson:"name"`
+	Policy        string    `bson:"policy,omitempty"` // Overrides NAME_PROPAGATION_POLICY (set by admin reconciles)
+	RequestedAt   time.Time `bson:"requested_at"`
+	NextAttemptAt time.Time `bson:"next_attempt_at"`
+	Attempts      int       `bson:"attempts,omitempty"`
+	LastError     string    `bson:"last_error,omitempty"`
+	// Set while a worker is running the job
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty"`
+}
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider.
+type ExternalIdentity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"subject"` // "sub" claim, stable per provider
+	Email    string    `bson:"email,omitempty" json:"email,omitempty"`
+	LinkedAt time.Time `bson:"linked_at" json:"linkedAt"`
+}
+
+// OrgMembership is a user's membership in an organization and their role in it.
+type OrgMembership struct {
+	OrgID    primitive.ObjectID `bson:"org_id" json:"orgId"`
+	Role     string             `bson:"role" json:"role"` // "owner", "admin" or "member"
+	JoinedAt time.Time          `bson:"joined_at" json:"joinedAt"`
+}
+
+// Organization is a tenant, such as a bootcamp running MockOrbit for its
+// cohorts. Members only see peers, interviews and topics of the organization
+// they are acting in.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Topics    []Topic            `bson:"topics,omitempty" json:"topics,omitempty"` // Replaces the default topic list if set
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OrganizationResponse is an organization as shown to one of its members.
+type OrganizationResponse struct {
+	Organization
+	Role string `json:"role"` // The caller's role in the organization
+}
+
+// OrgMemberResponse is a member as listed to the rest of the organization.
+type OrgMemberResponse struct {
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"name"`
+	Email    string             `json:"email"`
+	Role     string             `json:"role"`
+	JoinedAt time.Time          `json:"joinedAt"`
+}
+
+// OIDCLoginState is stored between redirecting to the provider and handling the
+// callback. It is looked up by the state parameter and deleted on use.
+type OIDCLoginState struct {
+	State        string    `bson:"_id"`
+	Provider     string    `bson:"provider"`
+	Nonce        string    `bson:"nonce"`
+	CodeVerifier string    `bson:"code_verifier"` // PKCE
+	Role         string    `bson:"role,omitempty"` // Role chosen during onboarding, for new accounts
+	ExpiresAt    time.Time `bson:"expires_at"`
+	CreatedAt    time.Time `bson:"createdAt"`
+}
+
 // UserResponse is the DTO (Data Transfer Object) for user data sent in API responses.
 // It explicitly excludes the password.
 type UserResponse struct {
@@ -28,11 +181,217 @@ type UserResponse struct {
 	Role              string             `json:"role"`
 	AvailableRoles    []string           `json:"availableRoles"`
 	ProfilePictureURL *string            `json:"profile_picture_url,omitempty"`
+	Timezone          string             `json:"timezone,omitempty"`
+	Topics            []string           `json:"topics,omitempty"`
+	Skills            []Skill            `json:"skills,omitempty"`
+	YearsOfExperience *int               `json:"yearsOfExperience,omitempty"`
+	TargetRoles       []string           `json:"targetRoles,omitempty"`
+	TargetCompanies   []string           `json:"targetCompanies,omitempty"`
+	Languages         []string           `json:"languages,omitempty"`
+	Bio               string             `json:"bio,omitempty"`
+	Links             []ProfileLink      `json:"links,omitempty"`
+	Privacy           ProfilePrivacy     `json:"privacy"`
+	EmailVerified     bool               `json:"emailVerified"`
+	MFAEnabled        bool               `json:"mfaEnabled"`
+	// Set while an email change waits for confirmation from the new address
+	PendingEmail string `json:"pendingEmail,omitempty"`
+	// Set if the account is due to be deleted; it can be cancelled until then
+	DeletionScheduledFor *time.Time `json:"deletionScheduledFor,omitempty"`
 	CreatedAt         time.Time          `json:"createdAt"`
 	UpdatedAt         time.Time          `json:"updatedAt"`
 }
 
 
+// AdminUserResponse is a user as shown in the admin API, including moderation state.
+type AdminUserResponse struct {
+	UserResponse
+	Banned       bool                `json:"banned"`
+	BannedAt     *time.Time          `json:"bannedAt,omitempty"`
+	BannedBy     *primitive.ObjectID `json:"bannedBy,omitempty"`
+	BannedReason string              `json:"bannedReason,omitempty"`
+}
+
+// AuditLog records an action taken through the admin API.
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    primitive.ObjectID     `bson:"actor_id" json:"actorId"`
+	ActorEmail string                 `bson:"actor_email" json:"actorEmail"`
+	Action     string                 `bson:"action" json:"action"` // e.g. "user.ban", "interview.cancel"
+	TargetType string                 `bson:"target_type" json:"targetType"`
+	TargetID   string                 `bson:"target_id" json:"targetId"`
+	Details    map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	IP         string                 `bson:"ip" json:"ip"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token.
+// Only the SHA-256 hash of the token is stored. Every token descended from a
+// single login shares a FamilyID so that reuse of a rotated token can revoke
+// the whole chain.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	FamilyID  primitive.ObjectID `bson:"family_id"`
+	TokenHash string             `bson:"token_hash"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`    // Set when the token is rotated
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty"` // Set when the family is revoked
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// RevokedToken is an entry in the access token revocation store, keyed by the token's jti.
+type RevokedToken struct {
+	ID        string             `bson:"_id"` // jti claim
+	UserID    primitive.ObjectID `bson:"user_id"`
+	ExpiresAt time.Time          `bson:"expires_at"` // Entry can be dropped once the token has expired
+	RevokedAt time.Time          `bson:"revoked_at"`
+}
+
+// Session is a login on one device. Its ID is also the family ID of the
+// refresh tokens issued for it and the "sid" claim of its access tokens.
+type Session struct {
+	ID          primitive.ObjectID  `bson:"_id"`
+	UserID      primitive.ObjectID  `bson:"user_id"`
+	UserAgent   string              `bson:"user_agent"`
+	IP          string              `bson:"ip"`
+	CreatedAt   time.Time           `bson:"createdAt"`
+	LastSeenAt  time.Time           `bson:"last_seen_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"` // Pushed back on every refresh
+	RevokedAt   *time.Time          `bson:"revoked_at,omitempty"`
+	ActiveRole  string              `bson:"active_role,omitempty"`   // Empty until switched; defaults to the signup role
+	ActiveOrgID *primitive.ObjectID `bson:"active_org_id,omitempty"` // Nil acts in the public space
+}
+
+// SessionResponse is a session as shown to its owner.
+type SessionResponse struct {
+	ID         primitive.ObjectID `json:"id"`
+	UserAgent  string             `json:"userAgent"`
+	IP         string             `json:"ip"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	LastSeenAt time.Time          `json:"lastSeenAt"`
+	Current    bool               `json:"current"` // The session making the request
+}
+
+// WSTicket is a one-time, short-lived ticket for opening an interview-room
+// WebSocket, so tokens never appear in the socket URL. It is issued either to
+// a user's session or to a guest.
+type WSTicket struct {
+	ID          primitive.ObjectID  `bson:"_id"`
+	TicketHash  string              `bson:"ticket_hash"`
+	InterviewID primitive.ObjectID  `bson:"interview_id"`
+	UserID      *primitive.ObjectID `bson:"user_id,omitempty"`
+	SessionID   *primitive.ObjectID `bson:"session_id,omitempty"`
+	TokenID     string              `bson:"token_id,omitempty"` // jti of the access token that asked for it
+	GuestID     *primitive.ObjectID `bson:"guest_id,omitempty"`
+	GuestName   string              `bson:"guest_name,omitempty"`
+	CreatedAt   time.Time           `bson:"createdAt"`
+	ExpiresAt   time.Time           `bson:"expires_at"` // TTL index
+}
+
+// PersonalAccessToken is a long-lived, scoped token for scripts and
+// integrations. Only the SHA-256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID  `bson:"user_id"`
+	Name       string              `bson:"name"`
+	TokenHash  string              `bson:"token_hash"`
+	Hint       string              `bson:"hint"` // Last characters of the token, to help users recognize it
+	Scopes     []string            `bson:"scopes"`
+	OrgID      *primitive.ObjectID `bson:"org_id,omitempty"` // Organization active when the token was created
+	ExpiresAt  time.Time           `bson:"expires_at"`
+	LastUsedAt *time.Time          `bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time          `bson:"revoked_at,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt"`
+}
+
+// PersonalAccessTokenResponse describes a token without revealing it.
+type PersonalAccessTokenResponse struct {
+	ID         primitive.ObjectID  `json:"id"`
+	Name       string              `json:"name"`
+	Hint       string              `json:"hint"`
+	Scopes     []string            `json:"scopes"`
+	OrgID      *primitive.ObjectID `json:"orgId,omitempty"`
+	ExpiresAt  time.Time           `json:"expiresAt"`
+	LastUsedAt *time.Time          `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+// RoleRequest is a user's request for an additional role. Requests start as
+// pending and are approved or rejected by an admin or by an auto-approval rule.
+type RoleRequest struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID  `bson:"user_id" json:"userId"`
+	UserName     string              `bson:"user_name" json:"userName"`
+	UserEmail    string              `bson:"user_email" json:"userEmail"`
+	Role         string              `bson:"role" json:"role"`
+	Reason       string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Status       string              `bson:"status" json:"status"` // "pending", "approved" or "rejected"
+	AutoApproved bool                `bson:"auto_approved,omitempty" json:"autoApproved,omitempty"`
+	DecidedBy    *primitive.ObjectID `bson:"decided_by,omitempty" json:"decidedBy,omitempty"`
+	DecisionNote string              `bson:"decision_note,omitempty" json:"decisionNote,omitempty"`
+	DecidedAt    *time.Time          `bson:"decided_at,omitempty" json:"decidedAt,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// UserBlock records that one user blocked another. Blocks work both ways:
+// neither user sees the other in the peer directory, schedules with them or
+// joins a room with them.
+type UserBlock struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BlockerID primitive.ObjectID `bson:"blocker_id" json:"blockerId"`
+	BlockedID primitive.ObjectID `bson:"blocked_id" json:"blockedId"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// BlockedUserResponse is an entry in the current user's block list.
+type BlockedUserResponse struct {
+	ID        primitive.ObjectID `json:"id"` // The blocked user
+	Name      string             `json:"name"`
+	BlockedAt time.Time          `json:"blockedAt"`
+}
+
+// UserReport is a report about a user's behaviour, waiting in the admin
+// moderation queue until it is resolved or dismissed.
+type UserReport struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ReporterID   primitive.ObjectID  `bson:"reporter_id" json:"reporterId"`
+	ReporterName string              `bson:"reporter_name" json:"reporterName"`
+	ReportedID   primitive.ObjectID  `bson:"reported_id" json:"reportedId"`
+	ReportedName string              `bson:"reported_name" json:"reportedName"`
+	Reason       string              `bson:"reason" json:"reason"` // See ReportUserInput for the categories
+	Details      string              `bson:"details,omitempty" json:"details,omitempty"`
+	InterviewID  *primitive.ObjectID `bson:"interview_id,omitempty" json:"interviewId,omitempty"`
+	// Chat messages are not stored, so this is the message as the reporter's
+	// client showed it
+	Message        *ReportedMessage    `bson:"message,omitempty" json:"message,omitempty"`
+	Status         string              `bson:"status" json:"status"` // "open", "resolved" or "dismissed"
+	ResolvedBy     *primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolvedBy,omitempty"`
+	ResolutionNote string              `bson:"resolution_note,omitempty" json:"resolutionNote,omitempty"`
+	ResolvedAt     *time.Time          `bson:"resolved_at,omitempty" json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// ReportedMessage is a chat message quoted in a report.
+type ReportedMessage struct {
+	Text      string `bson:"text" json:"text" binding:"required,max=2000"`
+	Timestamp int64  `bson:"timestamp" json:"timestamp"` // As sent in the chat-message event (Unix ms)
+}
+
+// SigningKey is a JWT signing key shared by all API replicas. A key is
+// published in the JWKS (and accepted for verification) from CreatedAt until
+// ExpiresAt, and signs new tokens from ActivatesAt until a newer key takes over
+// at RetiresAt.
+type SigningKey struct {
+	ID                  string    `bson:"_id"`                             // kid header
+	Algorithm           string    `bson:"algorithm"`                       // "RS256" or "EdDSA"
+	PrivateKeyPEM       string    `bson:"private_key_pem,omitempty"`       // PKCS#8; only without JWT_KEY_ENCRYPTION_KEY
+	EncryptedPrivateKey []byte    `bson:"encrypted_private_key,omitempty"` // The PEM sealed with AES-256-GCM: nonce, then ciphertext
+	CreatedAt           time.Time `bson:"createdAt"`
+	ActivatesAt         time.Time `bson:"activates_at,omitempty"` // Zero for keys that signed from creation
+	RetiresAt           time.Time `bson:"retires_at"`
+	ExpiresAt           time.Time `bson:"expires_at"`
+}
+
 // Interview represents the interview model in the database
 type Interview struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -40,24 +399,105 @@ type Interview struct {
 	IntervieweeID  primitive.ObjectID `bson:"interviewee_id" json:"interviewee_id"`
 	ScheduledTime time.Time          `bson:"scheduled_time" json:"scheduled_time"` // Store as UTC
 	Topic          string             `bson:"topic" json:"topic"` // Store the topic name or ID
-	Status         string             `bson:"status" json:"status"` // e.g., "scheduled", "in_progress", "completed", "cancelled"
+	Status         string             `bson:"status" json:"status"` // "scheduled", "in_progress", "completed", "cancelled" or "no_show"
+	OrgID          *primitive.ObjectID `bson:"org_id,omitempty" json:"org_id,omitempty"` // Nil for interviews in the public space
 	// Denormalized names for easier display in lists
 	InterviewerName string `bson:"interviewer_name" json:"interviewerName"`
 	IntervieweeName string `bson:"interviewee_name" json:"intervieweeName"`
 	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// People without an account invited through guest links. If a guest takes
+	// the interviewee slot, IntervieweeID stays nil until they claim it.
+	Guests []InterviewGuest `bson:"guests,omitempty" json:"guests,omitempty"`
+	// Every status change since scheduling, oldest first
+	StatusHistory []StatusChange `bson:"status_history,omitempty" json:"statusHistory,omitempty"`
+	// Every proposal to move the interview, oldest first. At most one is pending.
+	RescheduleProposals []RescheduleProposal `bson:"reschedule_proposals,omitempty" json:"rescheduleProposals,omitempty"`
 	// Consider adding fields for feedback later if needed
 	// FeedbackID primitive.ObjectID `bson:"feedback_id,omitempty" json:"feedback_id,omitempty"`
 	// InterviewerFeedbackProvided bool `bson:"interviewerFeedbackProvided,omitempty"`
 	// IntervieweeFeedbackReceived bool `bson:"intervieweeFeedbackReceived,omitempty"`
 }
 
+// InterviewGuest is someone without an account who joins an interview through
+// a guest link. Registering and verifying the same email claims the guest's
+// history for the new account.
+type InterviewGuest struct {
+	ID          primitive.ObjectID  `bson:"id" json:"id"`
+	Name        string              `bson:"name" json:"name"`
+	Email       string              `bson:"email" json:"email"` // Stored lowercase
+	Interviewee bool                `bson:"interviewee" json:"interviewee"` // Takes the interviewee slot
+	InvitedBy   primitive.ObjectID  `bson:"invited_by" json:"invitedBy"`
+	InvitedAt   time.Time           `bson:"invited_at" json:"invitedAt"`
+	ExpiresAt   time.Time           `bson:"expires_at" json:"expiresAt"` // Expiry of the guest link
+	JoinedAt    *time.Time          `bson:"joined_at,omitempty" json:"joinedAt,omitempty"`
+	RevokedAt   *time.Time          `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+	ClaimedBy   *primitive.ObjectID `bson:"claimed_by,omitempty" json:"claimedBy,omitempty"`
+	ClaimedAt   *time.Time          `bson:"claimed_at,omitempty" json:"claimedAt,omitempty"`
+}
+
+// StatusChange records who moved an interview from one status to another.
+type StatusChange struct {
+	From      string             `bson:"from" json:"from"`
+	To        string             `bson:"to" json:"to"`
+	ActorID   primitive.ObjectID `bson:"actor_id" json:"actorId"`
+	ActorRole string             `bson:"actor_role" json:"actorRole"` // "interviewer", "interviewee" or "admin"
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+}
+
+// RescheduleProposal offers the other participant new times for an
+// interview. They accept one of them, decline, or counter with times of their
+// own, which closes this proposal as "countered".
+type RescheduleProposal struct {
+	ID              primitive.ObjectID  `bson:"id" json:"id"`
+	ProposedBy      primitive.ObjectID  `bson:"proposed_by" json:"proposedBy"`
+	Times           []time.Time         `bson:"times" json:"times"`
+	Message         string              `bson:"message,omitempty" json:"message,omitempty"`
+	PreviousTime    time.Time           `bson:"previous_time" json:"previousTime"` // Scheduled time when proposed
+	Status          string              `bson:"status" json:"status"` // "pending", "accepted", "declined", "countered" or "withdrawn"
+	CreatedAt       time.Time           `bson:"created_at" json:"createdAt"`
+	RespondedBy     *primitive.ObjectID `bson:"responded_by,omitempty" json:"respondedBy,omitempty"`
+	RespondedAt     *time.Time          `bson:"responded_at,omitempty" json:"respondedAt,omitempty"`
+	ResponseMessage string              `bson:"response_message,omitempty" json:"responseMessage,omitempty"`
+	AcceptedTime    *time.Time          `bson:"accepted_time,omitempty" json:"acceptedTime,omitempty"`
+}
+
 // Simplified user info for embedding or responses
 type UserInfo struct {
 	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Name string             `bson:"name" json:"name"`
 }
 
+// PeerResponse is a user as listed in the peer directory.
+type PeerResponse struct {
+	ID                primitive.ObjectID `bson:"_id" json:"id"`
+	Name              string             `bson:"name" json:"name"`
+	ProfilePictureURL *string            `bson:"profile_picture_url,omitempty" json:"profile_picture_url,omitempty"`
+	AvailableRoles    []string           `bson:"availableRoles" json:"availableRoles"`
+	Topics            []string           `bson:"topics,omitempty" json:"topics,omitempty"`
+	Timezone          string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	Privacy           ProfilePrivacy     `bson:"privacy,omitempty" json:"-"`
+}
+
+// PeerProfileResponse is another user's profile as their peers see it. Fields
+// the user has hidden are left out.
+type PeerProfileResponse struct {
+	ID                primitive.ObjectID `json:"id"`
+	Name              string             `json:"name"`
+	ProfilePictureURL *string            `json:"profile_picture_url,omitempty"`
+	AvailableRoles    []string           `json:"availableRoles"`
+	Topics            []string           `json:"topics,omitempty"`
+	Timezone          string             `json:"timezone,omitempty"`
+	Skills            []Skill            `json:"skills,omitempty"`
+	YearsOfExperience *int               `json:"yearsOfExperience,omitempty"`
+	TargetRoles       []string           `json:"targetRoles,omitempty"`
+	TargetCompanies   []string           `json:"targetCompanies,omitempty"`
+	Languages         []string           `json:"languages,omitempty"`
+	Bio               string             `json:"bio,omitempty"`
+	Links             []ProfileLink      `json:"links,omitempty"`
+}
+
 // Response structure for interview lists/details (including populated participant info)
 type InterviewResponse struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -67,6 +507,9 @@ type InterviewResponse struct {
 	Topic         string             `bson:"topic" json:"topic"`
 	Status        string             `bson:"status" json:"status"`
 	FeedbackStatus string            `bson:"feedback_status,omitempty" json:"feedback_status,omitempty"` // Determined contextually in handler
+	Guests        []InterviewGuest   `bson:"guests,omitempty" json:"guests,omitempty"` // Only shown to the interviewer
+	StatusHistory []StatusChange     `bson:"status_history,omitempty" json:"statusHistory,omitempty"`
+	RescheduleProposals []RescheduleProposal `bson:"reschedule_proposals,omitempty" json:"rescheduleProposals,omitempty"`
 }
 
 type PerformanceStats struct {
@@ -77,8 +520,8 @@ type PerformanceStats struct {
 
 // Topic represents an interview topic choice
 type Topic struct {
-	ID   string `json:"id"`   // Unique ID for the topic
-	Name string `json:"name"` // Display name of the topic
+	ID   string `bson:"id" json:"id" binding:"required,max=50"`      // Unique ID for the topic
+	Name string `bson:"name" json:"name" binding:"required,max=100"` // Display name of the topic
 }
 
 // AvailableSlot represents a time slot for scheduling
@@ -94,9 +537,22 @@ type AvailableSlot struct {
 // Input struct for creating an interview
 type CreateInterviewInput struct {
 	InterviewerID string    `json:"interviewer_id" binding:"required,objectid"` // Add validation for ObjectID format
-	IntervieweeID string    `json:"interviewee_id" binding:"required,objectid"`
+	IntervieweeID string    `json:"interviewee_id" binding:"required_without=Guest,omitempty,objectid"`
 	ScheduledTime time.Time `json:"scheduled_time" binding:"required"` // Expect ISO 8601 format UTC
 	Topic         string    `json:"topic" binding:"required"`
+	// Invite someone without an account as the interviewee instead of IntervieweeID
+	Guest *GuestInviteInput `json:"guest" binding:"omitempty"`
+}
+
+// Input struct for a guest asking for a WebSocket ticket
+type GuestTicketInput struct {
+	Token string `json:"token" binding:"required"` // From the guest link
+}
+
+// Input struct for inviting a guest to an interview
+type GuestInviteInput struct {
+	Name  string `json:"name" binding:"required,min=2,max=100"`
+	Email string `json:"email" binding:"required,email"`
 }
 
 // Input struct for registering a user
@@ -113,10 +569,188 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// Input struct for exchanging a refresh token
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Input struct for switching the role a session acts in
+type SetActiveRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=interviewer interviewee"`
+}
+
+// Input struct for switching the organization a session acts in
+type SetActiveOrgInput struct {
+	OrgID string `json:"org_id" binding:"omitempty,objectid"` // Empty switches to the public space
+}
+
+// Input struct for creating an organization
+type CreateOrganizationInput struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+}
+
+// Input struct for updating an organization (org admins only)
+type UpdateOrganizationInput struct {
+	Name   *string  `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Topics *[]Topic `json:"topics,omitempty" binding:"omitempty,max=100,dive"` // Empty list restores the default topics
+}
+
+// Input struct for adding an existing user to an organization
+type AddOrgMemberInput struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"omitempty,oneof=owner admin member"` // Defaults to member
+}
+
+// Input struct for changing a member's role in an organization
+type UpdateOrgMemberInput struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+// Input struct for creating a personal access token
+type CreatePersonalAccessTokenInput struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // Defaults to 30
+}
+
+// Input struct for requesting an additional role
+type RoleRequestInput struct {
+	Role   string `json:"role" binding:"required,oneof=interviewer interviewee"`
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// Input struct for an admin's decision on a role request
+type RoleDecisionInput struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// Input struct for reporting a user
+type ReportUserInput struct {
+	Reason      string           `json:"reason" binding:"required,oneof=harassment inappropriate_content spam no_show cheating other"`
+	Details     string           `json:"details" binding:"max=2000"`
+	InterviewID string           `json:"interview_id,omitempty" binding:"omitempty,objectid"` // Interview the report is about
+	Message     *ReportedMessage `json:"message,omitempty"`                                   // Chat message the report is about
+}
+
+// Input struct for closing a report (admin)
+type ResolveReportInput struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// Input struct for an admin-triggered re-sync of names shown in interviews
+type ReconcileNamesInput struct {
+	Policy string `json:"policy" binding:"omitempty,oneof=future unfinished all"` // Defaults to NAME_PROPAGATION_POLICY
+}
+
+// Input struct for banning a user
+type BanUserInput struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// Input struct for replacing a user's roles
+type UpdateUserRolesInput struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,oneof=interviewer interviewee admin"`
+}
+
+// Input struct for force-cancelling an interview
+type CancelInterviewInput struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// Input struct for starting, completing, cancelling or marking an interview as a no-show
+type InterviewStatusInput struct {
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// Input struct for the reschedule negotiation of an interview. "propose" and
+// "counter" take Times; "accept" takes one of the proposal's times as Time.
+type RescheduleProposalInput struct {
+	Action     string      `json:"action" binding:"required,oneof=propose accept decline counter"`
+	ProposalID string      `json:"proposal_id" binding:"required_unless=Action propose,omitempty,objectid"` // The pending proposal being answered
+	Times      []time.Time `json:"times" binding:"max=5"`
+	Time       *time.Time  `json:"time"`
+	Message    string      `json:"message" binding:"max=1000"`
+}
+
+// Input struct for requesting a password reset email
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Input struct for completing a password reset
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// Input struct for changing the password of a logged-in user
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// Input struct for changing the current user's email address
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password"` // Required unless the account only uses social login
+}
+
+// Input struct for deleting the current user's account
+type DeleteAccountInput struct {
+	Password string `json:"password"` // Required unless the account only uses social login
+}
+
+// Input struct for confirming TOTP enrollment or regenerating recovery codes
+type TOTPCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Input struct for disabling TOTP
+type DisableTOTPInput struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Input struct for completing a login that requires a second factor.
+// Exactly one of Code and RecoveryCode must be set.
+type MFAVerifyInput struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// Input struct for exchanging the one-time code from an OIDC callback for a session
+type OIDCExchangeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
 // Input struct for updating user profile
 type UpdateProfileInput struct {
 	Name              *string `json:"name,omitempty" binding:"omitempty,min=2"` // Pointer allows distinguishing null/omitted from empty string
 	ProfilePictureURL *string `json:"profile_picture_url,omitempty" binding:"omitempty,url|eq="` // Allow URL or empty string "" to clear
+	Timezone          *string   `json:"timezone,omitempty" binding:"omitempty,max=64"`                 // IANA name, or "" to clear
+	Topics            *[]string `json:"topics,omitempty" binding:"omitempty,max=20,dive,min=1,max=50"` // Replaces the list
+	// Lists replace the stored list and an empty list clears it; "" clears the bio
+	Skills            *[]Skill            `json:"skills,omitempty" binding:"omitempty,max=30,dive"`
+	YearsOfExperience *int                `json:"yearsOfExperience,omitempty" binding:"omitempty,min=0,max=60"`
+	TargetRoles       *[]string           `json:"targetRoles,omitempty" binding:"omitempty,max=10,dive,min=1,max=100"`
+	TargetCompanies   *[]string           `json:"targetCompanies,omitempty" binding:"omitempty,max=20,dive,min=1,max=100"`
+	Languages         *[]string           `json:"languages,omitempty" binding:"omitempty,max=10,dive,bcp47_language_tag"`
+	Bio               *string             `json:"bio,omitempty" binding:"omitempty,max=1000"`
+	Links             *[]ProfileLink      `json:"links,omitempty" binding:"omitempty,max=5,dive"`
+	Privacy           *UpdatePrivacyInput `json:"privacy,omitempty"` // Only the flags sent are changed
+}
+
+// Input struct for changing profile privacy flags
+type UpdatePrivacyInput struct {
+	HideSkills          *bool `json:"hideSkills,omitempty"`
+	HideExperience      *bool `json:"hideExperience,omitempty"`
+	HideTargetRoles     *bool `json:"hideTargetRoles,omitempty"`
+	HideTargetCompanies *bool `json:"hideTargetCompanies,omitempty"`
+	HideTimezone        *bool `json:"hideTimezone,omitempty"`
+	HideLanguages       *bool `json:"hideLanguages,omitempty"`
+	HideBio             *bool `json:"hideBio,omitempty"`
+	HideLinks           *bool `json:"hideLinks,omitempty"`
 }
 
 // --- WebSocket Message Structs ---
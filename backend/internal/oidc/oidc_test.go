@@ -0,0 +1,315 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testClientID     = "mock-orbit"
+	testClientSecret = "s3cret"
+	testRedirectURL  = "http://api.test/api/v1/auth/oidc/mock/callback"
+)
+
+// mockIdP is a local OpenID provider: discovery, a JWKS that can be rotated
+// and a token endpoint that checks PKCE.
+type mockIdP struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	keys       map[string]interface{} // kid -> *rsa.PrivateKey | *ecdsa.PrivateKey
+	challenges map[string]string      // Authorization code -> S256 challenge
+	idToken    string                 // Returned by the token endpoint
+	jwksHits   int
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	idp := &mockIdP{keys: map[string]interface{}{}, challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.jwksHits++
+		keys := []map[string]string{}
+		for kid, key := range idp.keys {
+			switch k := key.(type) {
+			case *rsa.PrivateKey:
+				keys = append(keys, map[string]string{"kty": "RSA", "kid": kid, "use": "sig",
+					"n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())})
+			case *ecdsa.PrivateKey:
+				keys = append(keys, map[string]string{"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256",
+					"x": b64(k.X.FillBytes(make([]byte, 32))), "y": b64(k.Y.FillBytes(make([]byte, 32)))})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		id, secret, _ := r.BasicAuth()
+		if id != testClientID || secret != testClientSecret {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		challenge, ok := idp.challenges[r.PostForm.Get("code")]
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != testRedirectURL ||
+			b64(sum[:]) != challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		delete(idp.challenges, r.PostForm.Get("code"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "at", "token_type": "Bearer", "id_token": idp.idToken})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// addKey generates and publishes a signing key.
+func (idp *mockIdP) addKey(t *testing.T, kid string, ec bool) {
+	t.Helper()
+	var key interface{}
+	var err error
+	if ec {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.mu.Lock()
+	idp.keys[kid] = key
+	idp.mu.Unlock()
+}
+
+// sign issues an ID token with the given key, starting from valid claims for
+// the test client that the overrides replace (a nil value removes the claim).
+func (idp *mockIdP) sign(t *testing.T, kid string, overrides jwt.MapClaims) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss":            idp.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"nonce":          "nonce-1",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	idp.mu.Lock()
+	key := idp.keys[kid]
+	idp.mu.Unlock()
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (idp *mockIdP) provider() *Provider {
+	return &Provider{Config: config.OIDCProviderConfig{
+		Name:         "mock",
+		Issuer:       idp.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email"},
+	}}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.addKey(t, "rsa-1", false)
+	idp.addKey(t, "ec-1", true)
+	unpublished := newMockIdP(t)
+	unpublished.addKey(t, "rsa-1", false) // Same kid, different key
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": idp.URL, "aud": testClientID, "sub": "s", "nonce": "nonce-1", "exp": time.Now().Add(time.Minute).Unix()})
+	hmac.Header["kid"] = "rsa-1"
+	hmacToken, _ := hmac.SignedString([]byte("shared"))
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"iss": idp.URL, "aud": testClientID, "sub": "s", "nonce": "nonce-1", "exp": time.Now().Add(time.Minute).Unix()})
+	noneToken, _ := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		ok    bool
+	}{
+		{"RS256", idp.sign(t, "rsa-1", nil), "nonce-1", true},
+		{"ES256", idp.sign(t, "ec-1", nil), "nonce-1", true},
+		{"audience list", idp.sign(t, "rsa-1", jwt.MapClaims{"aud": []string{"other", testClientID}}), "nonce-1", true},
+		{"issuer with trailing slash", idp.sign(t, "rsa-1", jwt.MapClaims{"iss": idp.URL + "/"}), "nonce-1", true},
+		{"wrong issuer", idp.sign(t, "rsa-1", jwt.MapClaims{"iss": "https://evil.example"}), "nonce-1", false},
+		{"missing issuer", idp.sign(t, "rsa-1", jwt.MapClaims{"iss": nil}), "nonce-1", false},
+		{"wrong audience", idp.sign(t, "rsa-1", jwt.MapClaims{"aud": "other-client"}), "nonce-1", false},
+		{"audience list without us", idp.sign(t, "rsa-1", jwt.MapClaims{"aud": []string{"a", "b"}}), "nonce-1", false},
+		{"wrong nonce", idp.sign(t, "rsa-1", nil), "nonce-2", false},
+		{"missing nonce", idp.sign(t, "rsa-1", jwt.MapClaims{"nonce": nil}), "nonce-1", false},
+		{"expired", idp.sign(t, "rsa-1", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), "nonce-1", false},
+		{"missing exp", idp.sign(t, "rsa-1", jwt.MapClaims{"exp": nil}), "nonce-1", false},
+		{"missing sub", idp.sign(t, "rsa-1", jwt.MapClaims{"sub": nil}), "nonce-1", false},
+		{"HS256", hmacToken, "nonce-1", false},
+		{"alg none", noneToken, "nonce-1", false},
+		{"signed by another key with a known kid", unpublished.sign(t, "rsa-1", jwt.MapClaims{"iss": idp.URL}), "nonce-1", false},
+		{"malformed", "not.a.jwt", "nonce-1", false},
+	}
+	p := idp.provider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := p.VerifyIDToken(context.Background(), tt.token, tt.nonce)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("VerifyIDToken() = %v", err)
+				}
+				if claims.Subject != "subject-1" || claims.Email != "ada@example.com" || !claims.EmailVerified {
+					t.Errorf("unexpected claims %+v", claims)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("VerifyIDToken() accepted the token: %+v", claims)
+			}
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("VerifyIDToken() = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.addKey(t, "key-1", false)
+	p := idp.provider()
+
+	if _, err := p.VerifyIDToken(context.Background(), idp.sign(t, "key-1", nil), "nonce-1"); err != nil {
+		t.Fatalf("first key: %v", err)
+	}
+
+	idp.addKey(t, "key-2", false)
+	rotated := idp.sign(t, "key-2", nil)
+	// Unknown kids refetch the JWKS at most once per keyRefreshInterval
+	if _, err := p.VerifyIDToken(context.Background(), rotated, "nonce-1"); err == nil {
+		t.Fatal("accepted a new kid before the JWKS could be refetched")
+	}
+	p.mu.Lock()
+	p.keysFetched = time.Now().Add(-keyRefreshInterval)
+	p.mu.Unlock()
+	if _, err := p.VerifyIDToken(context.Background(), rotated, "nonce-1"); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+
+	forger := newMockIdP(t)
+	forger.addKey(t, "forged", false)
+	forged := forger.sign(t, "forged", jwt.MapClaims{"iss": idp.URL})
+	hits := idp.jwksHits
+	for i := 0; i < 3; i++ {
+		if _, err := p.VerifyIDToken(context.Background(), forged, "nonce-1"); err == nil {
+			t.Fatal("accepted an unknown kid")
+		}
+	}
+	if idp.jwksHits != hits {
+		t.Errorf("unknown kids fetched the JWKS %d more times, want none within the refresh interval", idp.jwksHits-hits)
+	}
+}
+
+func TestExchangeWithPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.addKey(t, "key-1", false)
+	idp.idToken = idp.sign(t, "key-1", nil)
+	p := idp.provider()
+
+	verifier, challenge, err := NewPKCEVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := parsed.Query()
+	if q.Get("code_challenge") != challenge || q.Get("code_challenge_method") != "S256" || q.Get("client_id") != testClientID ||
+		q.Get("redirect_uri") != testRedirectURL || q.Get("state") != "state-1" || q.Get("nonce") != "nonce-1" {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+
+	idp.mu.Lock()
+	idp.challenges["code-1"] = challenge
+	idp.challenges["code-2"] = challenge
+	idp.mu.Unlock()
+
+	if _, err := p.Exchange(context.Background(), "code-1", "wrong-verifier"); err == nil {
+		t.Error("Exchange() succeeded with the wrong PKCE verifier")
+	}
+	tokens, err := p.Exchange(context.Background(), "code-2", verifier)
+	if err != nil {
+		t.Fatalf("Exchange() = %v", err)
+	}
+	if tokens.IDToken != idp.idToken {
+		t.Errorf("Exchange() returned id token %q", tokens.IDToken)
+	}
+	if _, err := p.VerifyIDToken(context.Background(), tokens.IDToken, "nonce-1"); err != nil {
+		t.Errorf("VerifyIDToken(exchanged token) = %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), "code-2", verifier); err == nil {
+		t.Error("Exchange() reused an authorization code")
+	}
+
+	p.Config.ClientSecret = "wrong"
+	idp.mu.Lock()
+	idp.challenges["code-3"] = challenge
+	idp.mu.Unlock()
+	if _, err := p.Exchange(context.Background(), "code-3", verifier); err == nil {
+		t.Error("Exchange() succeeded with the wrong client secret")
+	}
+}
@@ -33,6 +33,9 @@ func SetupRouter() *gin.Engine {
         c.JSON(http.StatusOK, gin.H{"message": "pong"})
     })
 
+	// Public keys for verifying issued tokens
+	router.GET("/.well-known/jwks.json", handlers.JWKSHandler)
+
 
 	// API v1 Group
 	apiV1 := router.Group("/api/v1")
@@ -42,12 +45,27 @@ func SetupRouter() *gin.Engine {
 		{
 			auth.POST("/register", handlers.RegisterHandler)
 			auth.POST("/login", handlers.LoginHandler)
-			// Maybe add /refresh-token later
+			auth.POST("/refresh", handlers.RefreshHandler)
+			auth.POST("/logout", middleware.AuthMiddleware(), handlers.LogoutHandler)
+			auth.POST("/logout-all", middleware.AuthMiddleware(), handlers.LogoutAllHandler)
+			auth.GET("/verify", handlers.VerifyEmailHandler)
+			auth.POST("/verify/resend", middleware.AuthMiddleware(), handlers.ResendVerificationHandler)
+			auth.GET("/email/confirm", handlers.ConfirmEmailChangeHandler) // Link sent to the new address
+			auth.GET("/email/revert", handlers.RevertEmailChangeHandler)   // Link sent to the old address
+			auth.POST("/forgot-password", handlers.ForgotPasswordHandler)
+			auth.POST("/reset-password", handlers.ResetPasswordHandler)
+			auth.POST("/mfa/verify", handlers.VerifyMFAHandler)
+
+			// OpenID Connect social login
+			auth.GET("/oidc/providers", handlers.ListOIDCProvidersHandler)
+			auth.GET("/oidc/:provider/login", handlers.OIDCLoginHandler)
+			auth.GET("/oidc/:provider/callback", handlers.OIDCCallbackHandler)
+			auth.POST("/oidc/exchange", handlers.OIDCExchangeHandler)
 		}
 
 		// --- User Routes (Protected) ---
 		users := apiV1.Group("/users")
-		users.Use(middleware.AuthMiddleware()) // Apply auth middleware to all user routes
+		users.Use(middleware.ScopedAuthMiddleware("users")) // Apply auth middleware to all user routes
 		{
 			// Get current user's profile
 			// Note: No need for userId in path, get from token
@@ -57,33 +75,166 @@ func SetupRouter() *gin.Engine {
 			// Note: Use PATCH for partial updates
 			users.PATCH("/profile", handlers.UpdateUserProfileHandler) // Changed from /:userId to /profile
 
+			// Upload or remove the current user's profile picture
+			users.POST("/profile/avatar", handlers.UploadAvatarHandler)
+			users.DELETE("/profile/avatar", handlers.DeleteAvatarHandler)
+
+			// Ask for an additional role (e.g. interviewees who also want to interview)
+			users.POST("/roles/requests", middleware.RequireVerifiedEmail(), handlers.CreateRoleRequestHandler)
+			users.GET("/roles/requests", handlers.ListMyRoleRequestsHandler)
+
 			// Get list of peers (other users)
-			users.GET("/peers", handlers.GetPeersHandler)
+			users.GET("/peers", middleware.RequireVerifiedEmail(), handlers.GetPeersHandler)
+
+			// Get another user's profile, minus the fields they hide from peers
+			users.GET("/:userId/profile", middleware.RequireVerifiedEmail(), handlers.GetPeerProfileHandler)
+
+			// Block and report other users
+			users.GET("/blocks", handlers.ListBlockedUsersHandler)
+			users.POST("/:userId/block", handlers.BlockUserHandler)
+			users.DELETE("/:userId/block", handlers.UnblockUserHandler)
+			users.POST("/:userId/report", middleware.RequireVerifiedEmail(), handlers.ReportUserHandler)
 
 			// Get interviews for a specific user (using path param, but validated against token)
-			users.GET("/:userId/interviews", handlers.GetUserInterviewsHandler)
+			users.GET("/:userId/interviews", middleware.RequireVerifiedEmail(), handlers.GetUserInterviewsHandler)
 
 			// Get performance stats for a specific user (interviewer)
-			users.GET("/:userId/stats", middleware.RoleMiddleware("interviewer"), handlers.GetUserStatsHandler)
+			users.GET("/:userId/stats", middleware.RequireVerifiedEmail(), middleware.RoleMiddleware("interviewer"), handlers.GetUserStatsHandler)
+		}
+
+		// --- Account Security Routes (Protected, session only) ---
+		// Personal access tokens cannot reach these, so a leaked token can't be
+		// used to take over the account.
+		account := apiV1.Group("/users")
+		account.Use(middleware.AuthMiddleware())
+		{
+			// Change password (requires the current password)
+			account.PATCH("/password", handlers.ChangePasswordHandler)
+
+			// Change email (requires the current password; confirmed from the new address)
+			account.POST("/email", handlers.RequestEmailChangeHandler)
+
+			// Devices the user is logged in on
+			account.GET("/sessions", handlers.ListSessionsHandler)
+			account.DELETE("/sessions/:sessionId", handlers.RevokeSessionHandler)
+
+			// Switch the role the current session acts in (returns a new access token)
+			account.POST("/active-role", handlers.SetActiveRoleHandler)
+
+			// Switch the organization the current session acts in (returns a new access token)
+			account.POST("/active-org", handlers.SetActiveOrgHandler)
+
+			// Personal data export and account deletion (with a grace period)
+			account.GET("/export", handlers.ExportAccountHandler)
+			account.DELETE("/me", handlers.DeleteAccountHandler)
+			account.POST("/me/cancel-deletion", handlers.CancelAccountDeletionHandler)
+
+			// Personal access tokens for scripts and integrations
+			account.GET("/tokens", handlers.ListPersonalAccessTokensHandler)
+			account.POST("/tokens", handlers.CreatePersonalAccessTokenHandler)
+			account.DELETE("/tokens/:tokenId", handlers.RevokePersonalAccessTokenHandler)
+
+			// TOTP two-factor authentication
+			account.POST("/mfa/totp/enroll", handlers.EnrollTOTPHandler)
+			account.POST("/mfa/totp/confirm", handlers.ConfirmTOTPHandler)
+			account.POST("/mfa/totp/disable", handlers.DisableTOTPHandler)
+			account.POST("/mfa/recovery-codes", handlers.RegenerateRecoveryCodesHandler)
+		}
+
+		// --- Organization Routes (Protected, session only) ---
+		orgs := apiV1.Group("/orgs")
+		orgs.Use(middleware.AuthMiddleware(), middleware.RequireVerifiedEmail())
+		{
+			orgs.GET("", handlers.ListMyOrganizationsHandler)
+			orgs.POST("", handlers.CreateOrganizationHandler)
+			orgs.GET("/:orgId", handlers.GetOrganizationHandler)
+			orgs.PATCH("/:orgId", handlers.UpdateOrganizationHandler) // Name and topics (org admins)
+
+			// Members and their org roles (owner, admin, member)
+			orgs.GET("/:orgId/members", handlers.ListOrgMembersHandler)
+			orgs.POST("/:orgId/members", handlers.AddOrgMemberHandler)
+			orgs.PATCH("/:orgId/members/:userId", handlers.UpdateOrgMemberHandler)
+			orgs.DELETE("/:orgId/members/:userId", handlers.RemoveOrgMemberHandler) // Members can remove themselves to leave
 		}
 
 		// --- Interview Routes (Protected) ---
 		interviews := apiV1.Group("/interviews")
-		interviews.Use(middleware.AuthMiddleware())
+		interviews.Use(middleware.ScopedAuthMiddleware("interviews"), middleware.RequireVerifiedEmail())
 		{
 			// Schedule a new interview
-			interviews.POST("", handlers.CreateInterviewHandler) // Requires both roles potentially
+			interviews.POST("", handlers.CreateInterviewHandler) // Caller takes the slot of their active role
 
 			// Get details of a specific interview
 			interviews.GET("/:interviewId", handlers.GetInterviewDetailsHandler)
 
-			// TODO: Add routes for updating interview status (e.g., /:interviewId/start, /:interviewId/complete)
+			// Guest links for people without an account (interviewer only)
+			interviews.POST("/:interviewId/guests", middleware.RoleMiddleware("interviewer"), handlers.CreateGuestLinkHandler)
+			interviews.POST("/:interviewId/guests/:guestId/link", middleware.RoleMiddleware("interviewer"), handlers.ReissueGuestLinkHandler)
+			interviews.DELETE("/:interviewId/guests/:guestId", middleware.RoleMiddleware("interviewer"), handlers.RevokeGuestLinkHandler)
+
+			// Lifecycle: scheduled → in_progress → completed, or scheduled → cancelled / no_show
+			interviews.POST("/:interviewId/start", handlers.StartInterviewHandler)
+			interviews.POST("/:interviewId/complete", handlers.CompleteInterviewHandler)
+			interviews.POST("/:interviewId/cancel", handlers.CancelInterviewHandler)
+			interviews.POST("/:interviewId/no-show", handlers.NoShowInterviewHandler)
+
+			// Either participant proposes new times; the other accepts, declines or counters
+			interviews.PATCH("/:interviewId/reschedule-proposals", handlers.RescheduleProposalsHandler)
+
 			// TODO: Add routes for feedback (e.g., POST /:interviewId/feedback, GET /:interviewId/feedback)
 		}
 
+		// --- Interview Room Tickets ---
+		// One-time tickets for the WebSocket, so tokens stay out of its URL.
+		// Sessions only: personal access tokens can't join interview rooms.
+		roomTickets := apiV1.Group("/interviews")
+		roomTickets.Use(middleware.AuthMiddleware(), middleware.RequireVerifiedEmail())
+		{
+			roomTickets.POST("/:interviewId/ws-ticket", handlers.CreateWSTicketHandler)
+		}
+		// Guests authenticate with the token from their guest link instead
+		apiV1.POST("/interviews/:interviewId/guest-ws-ticket", handlers.CreateGuestWSTicketHandler)
+
+		// --- Uploaded Profile Pictures (Public) ---
+		// URLs change with every upload, so they are cached for good
+		apiV1.GET("/avatars/:file", handlers.GetAvatarHandler)
+
+		// --- Admin Routes (Protected) ---
+		admin := apiV1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			// Users: search, moderation and roles
+			admin.GET("/users", handlers.AdminListUsersHandler)
+			admin.GET("/users/:userId", handlers.AdminGetUserHandler)
+			admin.POST("/users/:userId/ban", handlers.AdminBanUserHandler)
+			admin.POST("/users/:userId/unban", handlers.AdminUnbanUserHandler)
+			admin.PUT("/users/:userId/roles", handlers.AdminUpdateUserRolesHandler)
+
+			// Moderation queue of user reports
+			admin.GET("/reports", handlers.ListReportsHandler)
+			admin.POST("/reports/:reportId/resolve", handlers.ResolveReportHandler)
+			admin.POST("/reports/:reportId/dismiss", handlers.DismissReportHandler)
+
+			// Interviews
+			admin.POST("/interviews/:interviewId/cancel", handlers.AdminCancelInterviewHandler)
+			admin.POST("/interviews/reconcile-names", handlers.AdminReconcileNamesHandler)
+
+			// Every action above is recorded here
+			admin.GET("/audit-logs", handlers.AdminListAuditLogsHandler)
+
+			// Lift login backoff/lockouts
+			admin.POST("/users/:userId/unlock", handlers.UnlockUserLoginHandler)
+			admin.DELETE("/login-lockouts/ip/:ip", handlers.UnlockIPLoginHandler)
+
+			// Role request queue
+			admin.GET("/role-requests", handlers.ListRoleRequestsHandler)
+			admin.POST("/role-requests/:requestId/approve", handlers.ApproveRoleRequestHandler)
+			admin.POST("/role-requests/:requestId/reject", handlers.RejectRoleRequestHandler)
+		}
+
         // --- General/Utility Routes (Protected) ---
         utils := apiV1.Group("") // Or specific group like /utils
-        utils.Use(middleware.AuthMiddleware())
+        utils.Use(middleware.ScopedAuthMiddleware("catalog"), middleware.RequireVerifiedEmail())
         {
             // Get list of topics
             utils.GET("/topics", handlers.GetTopicsHandler)
@@ -92,7 +243,7 @@ func SetupRouter() *gin.Engine {
         }
 	}
 
-    // WebSocket Route
+    // WebSocket Route (authenticated with a ticket from /interviews/:interviewId/ws-ticket)
     router.GET("/ws", handlers.WebsocketHandler)
 
 
@@ -0,0 +1,72 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// How often the memory store drops expired counters.
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. It is only suitable for a
+// single replica (and tests); use MongoStore when running several.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return Entry{}, nil
+	}
+	return e.Entry, nil
+}
+
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked(now)
+
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		e = memoryEntry{}
+	}
+	e.Failures++
+	e.LastFailure = now
+	e.expiresAt = now.Add(ttl)
+	s.entries[key] = e
+	return e.Entry, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
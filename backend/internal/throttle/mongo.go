@@ -0,0 +1,70 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"mock-orbit/backend/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps counters in the failed_attempts collection so that every
+// replica sees the same state. A TTL index on expires_at removes stale keys.
+type MongoStore struct{}
+
+type mongoEntry struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+func (s *MongoStore) Get(ctx context.Context, key string) (Entry, error) {
+	var doc mongoEntry
+	err := database.GetCollection("failed_attempts").FindOne(ctx, bson.M{
+		"_id":        key,
+		"expires_at": bson.M{"$gt": time.Now().UTC()},
+	}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return Entry{}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Failures: doc.Failures, LastFailure: doc.LastFailure}, nil
+}
+
+func (s *MongoStore) RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error) {
+	now = now.UTC()
+	// A pipeline update restarts the count when the existing document has
+	// expired but the TTL monitor has not removed it yet.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "failures", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$gt", Value: bson.A{"$expires_at", now}}},
+				bson.D{{Key: "$add", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$failures", 0}}}, 1}}},
+				1,
+			}}}},
+			{Key: "last_failure", Value: now},
+			{Key: "expires_at", Value: now.Add(ttl)},
+		}}},
+	}
+	var doc mongoEntry
+	err := database.GetCollection("failed_attempts").FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Failures: doc.Failures, LastFailure: doc.LastFailure}, nil
+}
+
+func (s *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := database.GetCollection("failed_attempts").DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,148 @@
+// Package throttle counts failed attempts (such as logins) per key and turns
+// them into exponential backoff and temporary lockouts. Counters live in a
+// pluggable Store so that several API replicas can share them.
+package throttle
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"mock-orbit/backend/internal/config"
+)
+
+// Entry is the failure history recorded for a key.
+type Entry struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Store persists failure counters. Implementations must be safe for concurrent
+// use and must forget a key once ttl has passed since its last failure.
+type Store interface {
+	Get(ctx context.Context, key string) (Entry, error)
+	// RecordFailure atomically increments the key's counter and returns the new entry.
+	RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (Entry, error)
+	Reset(ctx context.Context, key string) error
+}
+
+var (
+	current Store = NewMemoryStore()
+	mu      sync.RWMutex
+)
+
+// Setup selects the Store implementation from configuration.
+func Setup() {
+	switch config.AppConfig.LoginThrottleStore {
+	case "memory":
+		Set(NewMemoryStore())
+		log.Println("Throttle: using in-memory counters (not shared between replicas)")
+	default:
+		Set(&MongoStore{})
+		log.Println("Throttle: using MongoDB counters")
+	}
+}
+
+// Set replaces the active Store, e.g. with a fresh memory store in tests.
+func Set(s Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+func store() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Policy turns a failure count into a delay. The first FreeAttempts failures
+// cost nothing; after that each failure doubles the wait, starting at
+// BaseDelay and capped at MaxDelay. Reaching LockoutThreshold locks the key
+// for LockoutDuration.
+type Policy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// RetryAfter returns how long the key must wait before its next attempt and
+// whether it is locked out (as opposed to merely backing off).
+func (p Policy) RetryAfter(e Entry, now time.Time) (time.Duration, bool) {
+	if e.Failures == 0 {
+		return 0, false
+	}
+	if p.LockoutThreshold > 0 && e.Failures >= p.LockoutThreshold {
+		if wait := e.LastFailure.Add(p.LockoutDuration).Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, false
+	}
+	if e.Failures <= p.FreeAttempts {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < e.Failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if wait := e.LastFailure.Add(delay).Sub(now); wait > 0 {
+		return wait, false
+	}
+	return 0, false
+}
+
+// ttl is how long a counter is kept after its last failure. It outlives any
+// delay the policy can impose, so a quiet key eventually starts from zero.
+func (p Policy) ttl() time.Duration {
+	if p.LockoutDuration > p.MaxDelay {
+		return p.LockoutDuration
+	}
+	return p.MaxDelay
+}
+
+// Limiter applies a Policy to keys in one namespace (e.g. "login:account").
+type Limiter struct {
+	Name   string
+	Policy Policy
+}
+
+func (l *Limiter) key(key string) string {
+	return l.Name + ":" + key
+}
+
+// Check reports how long the key must wait before it may try again.
+func (l *Limiter) Check(ctx context.Context, key string) (time.Duration, bool, error) {
+	entry, err := store().Get(ctx, l.key(key))
+	if err != nil {
+		return 0, false, err
+	}
+	wait, locked := l.Policy.RetryAfter(entry, time.Now())
+	return wait, locked, nil
+}
+
+// Fail records a failed attempt and returns the resulting wait.
+func (l *Limiter) Fail(ctx context.Context, key string) (time.Duration, bool, error) {
+	now := time.Now()
+	entry, err := store().RecordFailure(ctx, l.key(key), now, l.Policy.ttl())
+	if err != nil {
+		return 0, false, err
+	}
+	wait, locked := l.Policy.RetryAfter(entry, now)
+	if locked && entry.Failures == l.Policy.LockoutThreshold {
+		log.Printf("Throttle: %s locked out for %s after %d failures", l.key(key), l.Policy.LockoutDuration, entry.Failures)
+	}
+	return wait, locked, nil
+}
+
+// Reset clears the key's failure history, e.g. after a successful login or an
+// admin unlock.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return store().Reset(ctx, l.key(key))
+}
@@ -0,0 +1,49 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyRetryAfter(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         10 * time.Second,
+		LockoutThreshold: 8,
+		LockoutDuration:  time.Hour,
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		since    time.Duration // Time since the last failure
+		wait     time.Duration
+		locked   bool
+	}{
+		{"no failures", policy, 0, 0, 0, false},
+		{"free attempts", policy, 3, 0, 0, false},
+		{"first delayed failure", policy, 4, 0, time.Second, false},
+		{"delay doubles", policy, 5, 0, 2 * time.Second, false},
+		{"delay doubles again", policy, 6, 0, 4 * time.Second, false},
+		{"delay below the cap", policy, 7, 0, 8 * time.Second, false},
+		{"part of the delay passed", policy, 5, 1500 * time.Millisecond, 500 * time.Millisecond, false},
+		{"delay passed", policy, 5, 2 * time.Second, 0, false},
+		{"locked out", policy, 8, 0, time.Hour, true},
+		{"lockout running", policy, 9, 20 * time.Minute, 40 * time.Minute, true},
+		{"lockout over", policy, 8, time.Hour, 0, false},
+		{"cap without lockout", Policy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}, 20, 0, 10 * time.Second, false},
+		{"cap not a power of two", Policy{BaseDelay: 3 * time.Second, MaxDelay: 10 * time.Second}, 3, 0, 10 * time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Entry{Failures: tt.failures, LastFailure: now.Add(-tt.since)}
+			wait, locked := tt.policy.RetryAfter(entry, now)
+			if wait != tt.wait || locked != tt.locked {
+				t.Errorf("RetryAfter(%d failures, %s ago) = %s, %v; want %s, %v", tt.failures, tt.since, wait, locked, tt.wait, tt.locked)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+"use client";
+
+import React, { useState } from "react";
+import Link from "next/link";
+import { motion } from "framer-motion";
+import { Mail, Loader2, Check, X, Command, Send } from "lucide-react";
+
+// --- API CONFIG ---
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
+
+// Requests a password reset email; the link in it opens /auth/reset-password
+export default function ForgotPasswordPage() {
+  const [email, setEmail] = useState("");
+  const [isLoading, setIsLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const [sent, setSent] = useState(false);
+
+  async function onSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    if (!email.trim()) return;
+    setIsLoading(true);
+    setError(null);
+    try {
+      const response = await fetch(`${API_URL}/auth/forgot-password`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ email: email.trim() }),
+      });
+      const data = await response.json().catch(() => ({}));
+      if (response.status === 429) {
+        const retryAfter = response.headers.get("Retry-After");
+        throw new Error(`Too many requests. Try again${retryAfter ? ` in ${retryAfter} seconds` : " later"}.`);
+      }
+      if (!response.ok) {
+        throw new Error(data.error || `Request failed: ${response.statusText}`);
+      }
+      // The same answer whether or not the account exists
+      setSent(true);
+    } catch (err: any) {
+      setError(String(err.message || "Could not request a reset link."));
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  return (
+    <div className="min-h-screen bg-[#000000] text-white flex items-center justify-center p-6 font-sans selection:bg-violet-500/30">
+      <div className="w-full max-w-[420px]">
+        <Link href="/" className="flex items-center justify-center gap-2 font-bold text-xl mb-8">
+          <div className="w-8 h-8 rounded-lg bg-violet-600 flex items-center justify-center"><Command className="w-4 h-4"/></div>
+          MockOrbit
+        </Link>
+
+        <div className="text-center mb-8">
+          <h2 className="text-3xl font-bold tracking-tight mb-2">Forgot Password</h2>
+          <p className="text-gray-400 text-sm">We'll email you a link to choose a new one.</p>
+        </div>
+
+        <div className="rounded-[1.5rem] bg-[#0A0A0A]/60 backdrop-blur-xl border border-white/10 p-1">
+          <div className="bg-[#050505]/80 rounded-[1.3rem] p-6 sm:p-8">
+            {sent ? (
+              <p className="text-sm text-emerald-300 flex items-center gap-2">
+                <Check className="w-4 h-4 flex-shrink-0" /> If an account uses this address, a reset link is on its way.
+              </p>
+            ) : (
+              <form onSubmit={onSubmit} className="space-y-6">
+                <div className="space-y-1.5">
+                  <label className="text-xs font-bold uppercase tracking-wider text-gray-500 px-1">Email Address</label>
+                  <div className="relative">
+                    <div className="absolute inset-y-0 left-0 pl-4 flex items-center pointer-events-none z-10">
+                      <Mail className="h-5 w-5 text-gray-500" />
+                    </div>
+                    <input
+                      type="email"
+                      value={email}
+                      onChange={(e) => setEmail(e.target.value)}
+                      autoComplete="email"
+                      placeholder="commander@orbit.com"
+                      className="w-full bg-[#0a0a0a]/50 backdrop-blur-xl border border-white/10 hover:border-white/20 focus:border-violet-500/50 text-white text-sm rounded-xl pl-11 pr-4 py-4 transition-all duration-300 outline-none placeholder:text-gray-700 shadow-inner"
+                    />
+                  </div>
+                </div>
+
+                {error && (
+                  <p className="text-[11px] text-red-400 flex items-center gap-1 px-1">
+                    <X className="w-3 h-3" /> {error}
+                  </p>
+                )}
+
+                <motion.button
+                  whileHover={{ scale: 1.02 }}
+                  whileTap={{ scale: 0.98 }}
+                  type="submit"
+                  disabled={isLoading || !email.trim()}
+                  className="w-full bg-white text-black font-bold rounded-xl py-3.5 hover:bg-gray-200 transition-all disabled:opacity-50 flex items-center justify-center gap-2 shadow-[0_0_20px_-5px_rgba(255,255,255,0.4)]"
+                >
+                  {isLoading ? (
+                    <><Loader2 className="w-4 h-4 animate-spin" /> Sending...</>
+                  ) : (
+                    <><Send className="w-4 h-4" /> Send Reset Link</>
+                  )}
+                </motion.button>
+              </form>
+            )}
+          </div>
+        </div>
+
+        <div className="text-center mt-8 text-sm">
+          <Link href="/auth/login" className="text-violet-400 hover:text-white transition-colors">Back to login</Link>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -224,6 +224,50 @@ export default function LoginPage() {
     mode: "onChange"
   });
 
+  // Set once the password is accepted for an account with 2FA enabled
+  const [mfaToken, setMfaToken] = useState<string | null>(null);
+  const [mfaCode, setMfaCode] = useState("");
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+
+  // Social login providers configured on the backend
+  const [providers, setProviders] = useState<{ name: string, display_name: string, login_url: string }[]>([]);
+
+  useEffect(() => {
+    fetch(`${API_URL}/auth/oidc/providers`)
+      .then(res => res.ok ? res.json() : [])
+      .then(data => setProviders(Array.isArray(data) ? data : []))
+      .catch(() => setProviders([]));
+
+    // A social login for an account with 2FA continues here for the second factor
+    const pendingMfa = sessionStorage.getItem('oidc_mfa_token');
+    if (pendingMfa) {
+      sessionStorage.removeItem('oidc_mfa_token');
+      setMfaToken(pendingMfa);
+    }
+  }, []);
+
+  function completeLogin(data: any) {
+    if (!data.user || !data.token) {
+      throw new Error("Invalid response from server. Missing user token."); 
+    }
+
+    // Execute login logic from context
+    login(data.token, data.user, {
+      refreshToken: data.refresh_token,
+      expiresIn: data.expires_in,
+      activeRole: data.active_role,
+    });
+    
+    setToast({ 
+      title: "Access Granted", 
+      message: "Welcome back, Commander. Initializing dashboard...", 
+      type: "success" 
+    });
+    
+    // Navigate to dashboard
+    router.push('/dashboard');
+  }
+
   async function onSubmit(values: LoginFormValues) {
     setIsLoading(true);
     setToast(null);
@@ -241,27 +285,55 @@ export default function LoginPage() {
       if (!response.ok) {
         throw new Error(data.error || `Login failed: ${response.statusText}`);
       }
-      
-      if (!data.user || !data.token) {
-        throw new Error("Invalid response from server. Missing user token."); 
+
+      // 2FA accounts get a short-lived challenge to complete with a code
+      if (data.mfa_required) {
+        setMfaToken(data.mfa_token);
+        setMfaCode("");
+        setUseRecoveryCode(false);
+        return;
       }
 
-      // Execute login logic from context
-      login(data.token, data.user);
-      
+      completeLogin(data);
+
+    } catch (error: any) {
       setToast({ 
-        title: "Access Granted", 
-        message: "Welcome back, Commander. Initializing dashboard...", 
-        type: "success" 
+        title: "Access Denied", 
+        message: String(error.message || "Invalid credentials."), 
+        type: "error" 
       });
-      
-      // Navigate to dashboard
-      router.push('/dashboard');
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  async function onSubmitMfa(e: React.FormEvent) {
+    e.preventDefault();
+    if (!mfaToken || !mfaCode.trim()) return;
+    setIsLoading(true);
+    setToast(null);
+    try {
+      const response = await fetch(`${API_URL}/auth/mfa/verify`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(useRecoveryCode
+          ? { mfa_token: mfaToken, recovery_code: mfaCode.trim() }
+          : { mfa_token: mfaToken, code: mfaCode.replace(/\s/g, "") }),
+      });
+      const data = await response.json();
+
+      if (!response.ok) {
+        // The challenge has expired or been used up; start over from the password
+        if (response.status === 401) setMfaToken(null);
+        throw new Error(data.error || `Verification failed: ${response.statusText}`);
+      }
+
+      completeLogin(data);
 
     } catch (error: any) {
       setToast({ 
         title: "Access Denied", 
-        message: String(error.message || "Invalid credentials."), 
+        message: String(error.message || "Invalid code."), 
         type: "error" 
       });
     } finally {
@@ -367,6 +439,57 @@ export default function LoginPage() {
                <BorderBeam /> 
                
                <div className="bg-[#050505]/80 rounded-[1.3rem] p-6 sm:p-8 relative z-10 overflow-hidden">
+                  {mfaToken ? (
+                  <form onSubmit={onSubmitMfa} className="space-y-6">
+                     <div className="space-y-1.5">
+                        <label className="text-xs font-bold uppercase tracking-wider text-gray-500 px-1">
+                           {useRecoveryCode ? "Recovery Code" : "Authenticator Code"}
+                        </label>
+                        <div className="relative">
+                           <div className="absolute inset-y-0 left-0 pl-4 flex items-center pointer-events-none z-10">
+                              <ShieldCheck className="h-5 w-5 text-gray-500" />
+                           </div>
+                           <input
+                             autoFocus
+                             value={mfaCode}
+                             onChange={(e) => setMfaCode(e.target.value)}
+                             inputMode={useRecoveryCode ? "text" : "numeric"}
+                             autoComplete="one-time-code"
+                             placeholder={useRecoveryCode ? "xxxxx-xxxxx" : "123456"}
+                             className="w-full bg-[#0a0a0a]/50 backdrop-blur-xl border border-white/10 hover:border-white/20 focus:border-violet-500/50 text-white text-sm rounded-xl pl-11 pr-4 py-4 transition-all duration-300 outline-none placeholder:text-gray-700 shadow-inner tracking-widest"
+                           />
+                        </div>
+                        <p className="text-[11px] text-gray-500 px-1">
+                           {useRecoveryCode
+                             ? "Enter one of the recovery codes you saved when enabling 2FA. Each code works once."
+                             : "Enter the 6-digit code from your authenticator app."}
+                        </p>
+                     </div>
+
+                     <motion.button
+                       whileHover={{ scale: 1.02 }}
+                       whileTap={{ scale: 0.98 }}
+                       type="submit"
+                       disabled={isLoading || !mfaCode.trim()}
+                       className="w-full bg-white text-black font-bold rounded-xl py-3.5 hover:bg-gray-200 transition-all disabled:opacity-50 flex items-center justify-center gap-2 shadow-[0_0_20px_-5px_rgba(255,255,255,0.4)] relative overflow-hidden"
+                     >
+                       {isLoading ? (
+                          <><Loader2 className="w-4 h-4 animate-spin" /> Verifying...</>
+                       ) : (
+                          <><ShieldCheck className="w-4 h-4" /> Verify</>
+                       )}
+                     </motion.button>
+
+                     <div className="flex justify-between text-[11px]">
+                        <button type="button" onClick={() => { setUseRecoveryCode(!useRecoveryCode); setMfaCode(""); }} className="text-gray-500 hover:text-violet-400 transition-colors">
+                           {useRecoveryCode ? "Use authenticator code" : "Use a recovery code"}
+                        </button>
+                        <button type="button" onClick={() => setMfaToken(null)} className="text-gray-500 hover:text-violet-400 transition-colors">
+                           Back to login
+                        </button>
+                     </div>
+                  </form>
+                  ) : (
                   <form onSubmit={form.handleSubmit(onSubmit)} className="space-y-6">
                      <div className="space-y-4">
                         <InputField name="email" label="Email Address" icon={Mail} type="email" placeholder="commander@orbit.com" form={form} />
@@ -393,7 +516,25 @@ export default function LoginPage() {
                           <><LogIn className="w-4 h-4" /> Access Console</>
                        )}
                      </motion.button>
+
+                     {providers.length > 0 && (
+                        <div className="space-y-3">
+                           <div className="flex items-center gap-3 text-[10px] uppercase tracking-wider text-gray-600">
+                              <div className="flex-1 h-px bg-white/10" /> Or continue with <div className="flex-1 h-px bg-white/10" />
+                           </div>
+                           {providers.map((provider) => (
+                              <a
+                                key={provider.name}
+                                href={`${new URL(API_URL).origin}${provider.login_url}`}
+                                className="w-full bg-white/5 border border-white/10 hover:border-white/20 hover:bg-white/10 text-white text-sm font-medium rounded-xl py-3 transition-all flex items-center justify-center gap-2"
+                              >
+                                <Globe className="w-4 h-4 text-gray-400" /> {provider.display_name}
+                              </a>
+                           ))}
+                        </div>
+                     )}
                   </form>
+                  )}
                </div>
             </div>
 
@@ -0,0 +1,114 @@
+"use client";
+
+import React, { Suspense, useEffect, useRef, useState } from "react";
+import Link from "next/link";
+import { useRouter, useSearchParams } from "next/navigation";
+import { Loader2, X, Command } from "lucide-react";
+import { useAuth } from "@/providers/AuthProvider";
+
+// --- API CONFIG ---
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
+
+// Error codes the backend callback redirects here with
+const ERROR_MESSAGES: Record<string, string> = {
+  provider_error: "The sign-in provider declined the request.",
+  invalid_state: "This sign-in link expired or was already used. Please try again.",
+  exchange_failed: "We couldn't complete sign-in with the provider. Please try again.",
+  invalid_id_token: "The provider's response couldn't be verified.",
+  email_in_use: "An account with this email already exists. Log in with your password instead.",
+  role_required: "No account uses this email yet. Choose a role on the sign-up page to continue.",
+  server_error: "Something went wrong on our side. Please try again.",
+};
+
+// Finishes a social login (/auth/oidc/callback?code=... or ?error=...)
+function OIDCCallback() {
+  const router = useRouter();
+  const params = useSearchParams();
+  const { login } = useAuth();
+  const [error, setError] = useState<string | null>(null);
+  const exchanged = useRef(false);
+
+  useEffect(() => {
+    const code = params.get("code");
+    const errorCode = params.get("error");
+    if (errorCode || !code) {
+      setError(ERROR_MESSAGES[errorCode || ""] || "Sign-in failed. Please try again.");
+      return;
+    }
+    // The code is single-use; don't spend it twice under StrictMode
+    if (exchanged.current) return;
+    exchanged.current = true;
+
+    (async () => {
+      try {
+        const response = await fetch(`${API_URL}/auth/oidc/exchange`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ code }),
+        });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+          throw new Error(data.error || `Sign-in failed: ${response.statusText}`);
+        }
+        if (data.mfa_required) {
+          // The login page asks for the second factor
+          sessionStorage.setItem('oidc_mfa_token', data.mfa_token);
+          router.replace('/auth/login');
+          return;
+        }
+        if (!data.user || !data.token) {
+          throw new Error("Invalid response from server. Missing user token.");
+        }
+        login(data.token, data.user, {
+          refreshToken: data.refresh_token,
+          expiresIn: data.expires_in,
+          activeRole: data.active_role,
+        });
+        router.replace('/dashboard');
+      } catch (err: any) {
+        setError(String(err.message || "Sign-in failed. Please try again."));
+      }
+    })();
+  }, [params, login, router]);
+
+  return (
+    <div className="min-h-screen bg-[#000000] text-white flex items-center justify-center p-6 font-sans selection:bg-violet-500/30">
+      <div className="w-full max-w-[420px]">
+        <Link href="/" className="flex items-center justify-center gap-2 font-bold text-xl mb-8">
+          <div className="w-8 h-8 rounded-lg bg-violet-600 flex items-center justify-center"><Command className="w-4 h-4"/></div>
+          MockOrbit
+        </Link>
+
+        <div className="rounded-[1.5rem] bg-[#0A0A0A]/60 backdrop-blur-xl border border-white/10 p-1">
+          <div className="bg-[#050505]/80 rounded-[1.3rem] p-6 sm:p-8">
+            {error ? (
+              <p className="text-sm text-red-300 flex items-center gap-2">
+                <X className="w-4 h-4 flex-shrink-0" /> {error}
+              </p>
+            ) : (
+              <p className="text-sm text-gray-400 flex items-center gap-2">
+                <Loader2 className="w-4 h-4 animate-spin" /> Signing you in...
+              </p>
+            )}
+          </div>
+        </div>
+
+        {error && (
+          <div className="text-center mt-8 text-sm text-gray-500 space-x-4">
+            <Link href="/auth/login" className="text-violet-400 hover:text-white transition-colors">Back to login</Link>
+            <Link href="/auth/register" className="text-violet-400 hover:text-white transition-colors">Create an account</Link>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
+
+export default function OIDCCallbackPage() {
+  // useSearchParams needs a Suspense boundary to prerender
+  return (
+    <Suspense>
+      <OIDCCallback />
+    </Suspense>
+  );
+}
@@ -270,6 +270,18 @@ export default function RegisterPage() {
     mode: "onChange"
   });
 
+  // Social sign-up providers configured on the backend
+  const [providers, setProviders] = useState<{ name: string, display_name: string, login_url: string }[]>([]);
+
+  useEffect(() => {
+    fetch(`${API_URL}/auth/oidc/providers`)
+      .then(res => res.ok ? res.json() : [])
+      .then(data => setProviders(Array.isArray(data) ? data : []))
+      .catch(() => setProviders([]));
+  }, []);
+
+  const selectedRole = form.watch("role");
+
   const passwordValue = form.watch("password");
   useEffect(() => {
     let score = 0;
@@ -519,6 +531,30 @@ export default function RegisterPage() {
                                    <>Launch Account <ArrowRight className="w-4 h-4" /></>
                                 )}
                               </motion.button>
+
+                              {providers.length > 0 && (
+                                 <div className="space-y-3">
+                                    <div className="flex items-center gap-3 text-[10px] uppercase tracking-wider text-gray-600">
+                                       <div className="flex-1 h-px bg-white/10" /> Or sign up with <div className="flex-1 h-px bg-white/10" />
+                                    </div>
+                                    {providers.map((provider) => (
+                                       <a
+                                         key={provider.name}
+                                         href={selectedRole ? `${new URL(API_URL).origin}${provider.login_url}?role=${selectedRole}` : undefined}
+                                         aria-disabled={!selectedRole}
+                                         className={cn(
+                                           "w-full bg-white/5 border border-white/10 text-white text-sm font-medium rounded-xl py-3 transition-all flex items-center justify-center gap-2",
+                                           selectedRole ? "hover:border-white/20 hover:bg-white/10" : "opacity-50 cursor-not-allowed"
+                                         )}
+                                       >
+                                         <Globe className="w-4 h-4 text-gray-400" /> {provider.display_name}
+                                       </a>
+                                    ))}
+                                    {!selectedRole && (
+                                       <p className="text-[11px] text-gray-500 text-center">Select a role above to sign up with a provider.</p>
+                                    )}
+                                 </div>
+                              )}
                            </motion.div>
                         )}
                      </AnimatePresence>
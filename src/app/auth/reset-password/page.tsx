@@ -0,0 +1,146 @@
+"use client";
+
+import React, { Suspense, useState } from "react";
+import Link from "next/link";
+import { useRouter, useSearchParams } from "next/navigation";
+import { motion } from "framer-motion";
+import { Lock, Loader2, Check, X, Command, KeyRound } from "lucide-react";
+
+// --- API CONFIG ---
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
+
+// Completes a password reset from the emailed link (/auth/reset-password?token=...)
+function ResetPasswordForm() {
+  const router = useRouter();
+  const token = useSearchParams().get("token") || "";
+
+  const [password, setPassword] = useState("");
+  const [confirm, setConfirm] = useState("");
+  const [isLoading, setIsLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const [done, setDone] = useState(false);
+
+  async function onSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    if (password.length < 6) {
+      setError("Password must be at least 6 characters.");
+      return;
+    }
+    if (password !== confirm) {
+      setError("Passwords don't match.");
+      return;
+    }
+    setIsLoading(true);
+    setError(null);
+    try {
+      const response = await fetch(`${API_URL}/auth/reset-password`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ token, new_password: password }),
+      });
+      const data = await response.json().catch(() => ({}));
+      if (!response.ok) {
+        throw new Error(data.error || `Reset failed: ${response.statusText}`);
+      }
+      setDone(true);
+      // Every session was signed out; log in again with the new password
+      setTimeout(() => router.push('/auth/login'), 2000);
+    } catch (err: any) {
+      setError(String(err.message || "Could not reset the password."));
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  const inputClass = "w-full bg-[#0a0a0a]/50 backdrop-blur-xl border border-white/10 hover:border-white/20 focus:border-violet-500/50 text-white text-sm rounded-xl pl-11 pr-4 py-4 transition-all duration-300 outline-none placeholder:text-gray-700 shadow-inner";
+
+  return (
+    <div className="min-h-screen bg-[#000000] text-white flex items-center justify-center p-6 font-sans selection:bg-violet-500/30">
+      <div className="w-full max-w-[420px]">
+        <Link href="/" className="flex items-center justify-center gap-2 font-bold text-xl mb-8">
+          <div className="w-8 h-8 rounded-lg bg-violet-600 flex items-center justify-center"><Command className="w-4 h-4"/></div>
+          MockOrbit
+        </Link>
+
+        <div className="text-center mb-8">
+          <h2 className="text-3xl font-bold tracking-tight mb-2">Reset Password</h2>
+          <p className="text-gray-400 text-sm">Choose a new password for your account.</p>
+        </div>
+
+        <div className="rounded-[1.5rem] bg-[#0A0A0A]/60 backdrop-blur-xl border border-white/10 p-1">
+          <div className="bg-[#050505]/80 rounded-[1.3rem] p-6 sm:p-8">
+            {!token ? (
+              <p className="text-sm text-red-300 flex items-center gap-2">
+                <X className="w-4 h-4" /> This reset link is incomplete. Request a new one.
+              </p>
+            ) : done ? (
+              <p className="text-sm text-emerald-300 flex items-center gap-2">
+                <Check className="w-4 h-4" /> Password updated. Redirecting to login...
+              </p>
+            ) : (
+              <form onSubmit={onSubmit} className="space-y-6">
+                <div className="space-y-4">
+                  {[
+                    { label: "New Password", value: password, set: setPassword, autoComplete: "new-password" },
+                    { label: "Confirm Password", value: confirm, set: setConfirm, autoComplete: "new-password" },
+                  ].map((field) => (
+                    <div key={field.label} className="space-y-1.5">
+                      <label className="text-xs font-bold uppercase tracking-wider text-gray-500 px-1">{field.label}</label>
+                      <div className="relative">
+                        <div className="absolute inset-y-0 left-0 pl-4 flex items-center pointer-events-none z-10">
+                          <Lock className="h-5 w-5 text-gray-500" />
+                        </div>
+                        <input
+                          type="password"
+                          value={field.value}
+                          onChange={(e) => field.set(e.target.value)}
+                          autoComplete={field.autoComplete}
+                          placeholder="••••••••"
+                          className={inputClass}
+                        />
+                      </div>
+                    </div>
+                  ))}
+                </div>
+
+                {error && (
+                  <p className="text-[11px] text-red-400 flex items-center gap-1 px-1">
+                    <X className="w-3 h-3" /> {error}
+                  </p>
+                )}
+
+                <motion.button
+                  whileHover={{ scale: 1.02 }}
+                  whileTap={{ scale: 0.98 }}
+                  type="submit"
+                  disabled={isLoading || !password || !confirm}
+                  className="w-full bg-white text-black font-bold rounded-xl py-3.5 hover:bg-gray-200 transition-all disabled:opacity-50 flex items-center justify-center gap-2 shadow-[0_0_20px_-5px_rgba(255,255,255,0.4)]"
+                >
+                  {isLoading ? (
+                    <><Loader2 className="w-4 h-4 animate-spin" /> Updating...</>
+                  ) : (
+                    <><KeyRound className="w-4 h-4" /> Set New Password</>
+                  )}
+                </motion.button>
+              </form>
+            )}
+          </div>
+        </div>
+
+        <div className="text-center mt-8 text-sm text-gray-500 space-x-4">
+          <Link href="/auth/forgot-password" className="text-violet-400 hover:text-white transition-colors">Request a new link</Link>
+          <Link href="/auth/login" className="text-violet-400 hover:text-white transition-colors">Back to login</Link>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default function ResetPasswordPage() {
+  // useSearchParams needs a Suspense boundary to prerender
+  return (
+    <Suspense>
+      <ResetPasswordForm />
+    </Suspense>
+  );
+}
@@ -1,7 +1,7 @@
 "use client";
 
-import React, { useState, useEffect, useRef, useCallback } from 'react';
-import { useParams, useRouter } from 'next/navigation';
+import React, { Suspense, useState, useEffect, useRef, useCallback } from 'react';
+import { useParams, useRouter, useSearchParams } from 'next/navigation';
 import { 
   Mic, MicOff, Video, VideoOff, MessagesSquare, Code as CodeIcon, Hand, Send, 
   Maximize, Minimize, User, Wifi, Loader2, AlertCircle, CameraOff, 
@@ -140,13 +140,20 @@ const VideoFrame = ({ stream, label, muted, isLocal = false }: { stream: MediaSt
   );
 };
 
-export default function InterviewRoomPage() {
+function InterviewRoom() {
   const params = useParams();
   const router = useRouter();
   const { toast } = useToast();
   const { user, token, isLoading: isAuthLoading } = useAuth();
   
   const interviewId = typeof params.id === 'string' ? params.id : '';
+  // Set when joining from a guest link (/interview-room/:id?guest=<token>)
+  const guestToken = useSearchParams().get('guest');
+  // Without an account, a guest's identity in the room comes with its ticket
+  const [guest, setGuest] = useState<Participant | null>(null);
+  const selfId = user?.id ?? guest?.id;
+  // The socket's handlers keep the render they were made in; they read the guest's id from here
+  const guestIdRef = useRef<string | null>(null);
   
   // Logic State
   const [interviewDetails, setInterviewDetails] = useState<InterviewDetails | null>(null);
@@ -221,19 +228,23 @@ export default function InterviewRoomPage() {
   // --- INITIALIZATION ---
   useEffect(() => {
     if (isAuthLoading) return;
-    if (!token || !interviewId) {
+    const asGuest = !token && !!guestToken;
+    if ((!token && !asGuest) || !interviewId) {
         router.push('/dashboard');
         return;
     }
 
     const init = async () => {
         try {
-            const res = await fetch(`${API_URL}/interviews/${interviewId}`, {
-                headers: { Authorization: `Bearer ${token}` },
-            });
-            const data = await res.json();
-            if (!res.ok) throw new Error(data.error || 'Failed to load mission data');
-            setInterviewDetails(data);
+            // A guest can't load the interview; its ticket below comes with what the room shows
+            if (!asGuest) {
+                const res = await fetch(`${API_URL}/interviews/${interviewId}`, {
+                    headers: { Authorization: `Bearer ${token}` },
+                });
+                const data = await res.json();
+                if (!res.ok) throw new Error(data.error || 'Failed to load mission data');
+                setInterviewDetails(data);
+            }
             
             // Setup Media
             try {
@@ -244,8 +255,26 @@ export default function InterviewRoomPage() {
                 setMediaError("Camera/Mic inaccessible");
             }
 
-            // Setup WebSocket
-            const wsUrl = `${WEBSOCKET_PROTOCOL}://${WEBSOCKET_HOST}${WEBSOCKET_PATH}?interviewId=${interviewId}&userId=${user?.id}&token=${token}`;
+            // Setup WebSocket with a one-time ticket so the token stays out of the URL
+            const ticketRes = asGuest
+                ? await fetch(`${API_URL}/interviews/${interviewId}/guest-ws-ticket`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ token: guestToken }),
+                })
+                : await fetch(`${API_URL}/interviews/${interviewId}/ws-ticket`, {
+                    method: 'POST',
+                    headers: { Authorization: `Bearer ${token}` },
+                });
+            const ticketData = await ticketRes.json();
+            if (!ticketRes.ok) throw new Error(ticketData.error || 'Failed to get room ticket');
+            if (asGuest) {
+                const details: InterviewDetails = ticketData.interview;
+                guestIdRef.current = ticketData.client_id;
+                setInterviewDetails(details);
+                setGuest(details.interviewee.id === ticketData.client_id ? details.interviewee : { id: ticketData.client_id, name: 'Guest' });
+            }
+            const wsUrl = `${WEBSOCKET_PROTOCOL}://${WEBSOCKET_HOST}${WEBSOCKET_PATH}?interviewId=${interviewId}&ticket=${encodeURIComponent(ticketData.ticket)}`;
             const ws = new WebSocket(wsUrl);
             wsRef.current = ws;
 
@@ -265,15 +294,16 @@ export default function InterviewRoomPage() {
     init();
     return () => cleanupResources();
   // eslint-disable-next-line react-hooks/exhaustive-deps
-  }, [interviewId, token, isAuthLoading]);
+  }, [interviewId, token, guestToken, isAuthLoading]);
 
   // --- WEBSOCKET HANDLER ---
   const handleWebSocketMessage = (data: WebSocketMessage) => {
+    const selfId = user?.id ?? guestIdRef.current;
     switch (data.type) {
         case 'all-users':
             data.users?.forEach(u => {
-                if (u.id !== user?.id && !peersRef.current[u.id] && localStreamRef.current) {
-                    const peer = createPeer(u.id, user!.id, localStreamRef.current);
+                if (u.id !== selfId && !peersRef.current[u.id] && localStreamRef.current) {
+                    const peer = createPeer(u.id, selfId!, localStreamRef.current);
                     peersRef.current[u.id] = peer;
                     identifyParticipant(u.id);
                 }
@@ -295,11 +325,11 @@ export default function InterviewRoomPage() {
             if (data.message) setChatMessages(prev => [...prev, data.message]);
             break;
         case 'code-update':
-            if (data.code !== undefined && data.senderId !== user?.id) setCode(data.code);
-            if (data.language && data.senderId !== user?.id) setLanguage(data.language);
+            if (data.code !== undefined && data.senderId !== selfId) setCode(data.code);
+            if (data.language && data.senderId !== selfId) setLanguage(data.language);
             break;
         case 'whiteboard-update':
-             if (data.senderId !== user?.id) handleDraw(data.data);
+             if (data.senderId !== selfId) handleDraw(data.data);
              break;
     }
   };
@@ -323,7 +353,7 @@ export default function InterviewRoomPage() {
   const identifyParticipant = (id: string) => {
       if (!interviewDetails) return;
       const part = id === interviewDetails.interviewer.id ? interviewDetails.interviewer : interviewDetails.interviewee;
-      if (part.id !== user?.id) setOtherParticipant(part);
+      if (part.id !== (user?.id ?? guestIdRef.current)) setOtherParticipant(part);
   };
 
   const sendWS = (msg: any) => {
@@ -332,8 +362,9 @@ export default function InterviewRoomPage() {
 
   // --- ACTIONS ---
   const handleSendMessage = () => {
-      if (!newMessage.trim() || !user) return;
-      const msg = { senderId: user.id, senderName: user.name, text: newMessage, timestamp: Date.now() };
+      const self = user ?? guest;
+      if (!newMessage.trim() || !self) return;
+      const msg = { senderId: self.id, senderName: self.name, text: newMessage, timestamp: Date.now() };
       sendWS({ type: 'chat-message', message: msg });
       setChatMessages(prev => [...prev, msg]);
       setNewMessage('');
@@ -342,7 +373,7 @@ export default function InterviewRoomPage() {
   const handleCodeChange = (value: string | undefined) => {
       if (value !== undefined) {
           setCode(value);
-          sendWS({ type: 'code-update', code: value, language, senderId: user?.id });
+          sendWS({ type: 'code-update', code: value, language, senderId: selfId });
       }
   };
 
@@ -358,9 +389,9 @@ export default function InterviewRoomPage() {
       // OR if it's practically empty
       if (!code || code.trim() === currentDefault.trim() || code.length < 50) {
           setCode(newDefault);
-          sendWS({ type: 'code-update', code: newDefault, language: newLang, senderId: user?.id });
+          sendWS({ type: 'code-update', code: newDefault, language: newLang, senderId: selfId });
       } else {
-          sendWS({ type: 'code-update', code, language: newLang, senderId: user?.id }); 
+          sendWS({ type: 'code-update', code, language: newLang, senderId: selfId }); 
       }
   };
 
@@ -417,7 +448,7 @@ export default function InterviewRoomPage() {
       drawingContext.ctx.lineWidth = 2;
       drawingContext.ctx.stroke();
 
-      sendWS({ type: 'whiteboard-update', data: { x0: drawingContext.lastX, y0: drawingContext.lastY, x1: x, y1: y, color: '#FFFFFF', type: 'draw' }, senderId: user?.id });
+      sendWS({ type: 'whiteboard-update', data: { x0: drawingContext.lastX, y0: drawingContext.lastY, x1: x, y1: y, color: '#FFFFFF', type: 'draw' }, senderId: selfId });
       drawingContext.lastX = x;
       drawingContext.lastY = y;
   };
@@ -488,7 +519,7 @@ export default function InterviewRoomPage() {
                 
                 <Button 
                     variant="destructive" 
-                    onClick={() => router.push('/dashboard')} 
+                    onClick={() => router.push(user ? '/dashboard' : '/')} 
                     className="h-9 bg-red-600/10 text-red-500 border border-red-600/20 hover:bg-red-600 hover:text-white transition-all font-bold text-xs tracking-wider"
                 >
                     <PhoneOff className="w-3 h-3 mr-2" /> ABORT
@@ -598,7 +629,7 @@ export default function InterviewRoomPage() {
                             onChange={(val) => {
                                 if (val) {
                                     setCode(val);
-                                    sendWS({ type: 'code-update', code: val, language, senderId: user?.id });
+                                    sendWS({ type: 'code-update', code: val, language, senderId: selfId });
                                 }
                             }}
                             options={{
@@ -693,11 +724,11 @@ export default function InterviewRoomPage() {
                                             initial={{ opacity: 0, y: 10 }}
                                             animate={{ opacity: 1, y: 0 }}
                                             key={i} 
-                                            className={cn("flex flex-col max-w-[85%]", msg.senderId === user?.id ? "ml-auto items-end" : "items-start")}
+                                            className={cn("flex flex-col max-w-[85%]", msg.senderId === selfId ? "ml-auto items-end" : "items-start")}
                                         >
                                             <div className={cn(
                                                 "px-4 py-2.5 rounded-2xl text-sm shadow-md border",
-                                                msg.senderId === user?.id 
+                                                msg.senderId === selfId 
                                                     ? "bg-cyan-900/30 border-cyan-500/30 text-cyan-100 rounded-tr-sm" 
                                                     : "bg-white/10 border-white/10 text-gray-200 rounded-tl-sm"
                                             )}>
@@ -754,6 +785,15 @@ export default function InterviewRoomPage() {
   );
 }
 
+export default function InterviewRoomPage() {
+  // useSearchParams needs a Suspense boundary to prerender
+  return (
+    <Suspense>
+      <InterviewRoom />
+    </Suspense>
+  );
+}
+
 
 //   // --- INTERNAL HELPERS ---
 //   function sendWS(msg: any) {
@@ -1,9 +1,14 @@
 
 "use client";
 
-import React, { createContext, useContext, useState, useEffect, ReactNode, useCallback } from 'react';
+import React, { createContext, useContext, useState, useEffect, ReactNode, useCallback, useRef } from 'react';
 import { useRouter, usePathname } from 'next/navigation';
 
+const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
+
+// Access tokens are refreshed this long before they expire
+const REFRESH_MARGIN_MS = 60 * 1000;
+
 // Assume a user might have multiple roles, but only one is active at a time.
 // The backend should ideally provide all possible roles on login.
 // For now, we assume the initial 'role' is the default or primary one.
@@ -18,15 +23,24 @@ interface User {
 
 type ActiveRole = 'interviewer' | 'interviewee';
 
+// What the login endpoints return along with the access token
+interface SessionInfo {
+  refreshToken?: string;
+  expiresIn?: number; // Seconds until the access token expires
+  activeRole?: ActiveRole; // Role the server issued the token for
+}
+
 interface AuthContextType {
   user: User | null;
   token: string | null;
   isLoading: boolean;
   activeRole: ActiveRole | null; // Currently active role for the UI
   canSwitchRole: boolean; // Can the user switch roles?
-  login: (newToken: string, userData: User) => void;
+  login: (newToken: string, userData: User, session?: SessionInfo) => void;
   logout: () => void;
-  switchRole: () => void; // Function to toggle the active role
+  // fetch with the access token attached; on a 401 the session is refreshed and the request retried once
+  authFetch: (input: string, init?: RequestInit) => Promise<Response>;
+  switchRole: () => Promise<void>; // Switches the session to the other role
 }
 
 const AuthContext = createContext<AuthContextType | undefined>(undefined);
@@ -40,33 +54,122 @@ const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   const [token, setToken] = useState<string | null>(null);
   const [isLoading, setIsLoading] = useState(true); // Start loading initially
   const [activeRole, setActiveRole] = useState<ActiveRole | null>(null);
+  const [expiresAt, setExpiresAt] = useState<number | null>(null); // Access token expiry, ms since epoch
   const router = useRouter();
   const pathname = usePathname();
+  // Latest access token for callbacks, and the refresh in flight so concurrent callers share it
+  const tokenRef = useRef<string | null>(null);
+  const refreshPromise = useRef<Promise<string | null> | null>(null);
+
+  const storeAccessToken = useCallback((newToken: string, expiresIn?: number) => {
+    localStorage.setItem('authToken', newToken);
+    tokenRef.current = newToken;
+    setToken(newToken);
+    if (expiresIn) {
+      const newExpiresAt = Date.now() + expiresIn * 1000;
+      localStorage.setItem('authTokenExpiresAt', String(newExpiresAt));
+      setExpiresAt(newExpiresAt);
+    }
+  }, []);
+
+  const clearSession = useCallback(() => {
+    localStorage.removeItem('authToken');
+    localStorage.removeItem('authUser');
+    localStorage.removeItem('activeRole');
+    localStorage.removeItem('authRefreshToken');
+    localStorage.removeItem('authTokenExpiresAt');
+    tokenRef.current = null;
+    setToken(null);
+    setUser(null);
+    setActiveRole(null);
+    setExpiresAt(null);
+  }, []);
+
+  // Exchanges the refresh token for a new token pair. Refresh tokens are
+  // single use, so only one exchange runs at a time. Resolves to the new
+  // access token, or null once the session is gone.
+  const refreshSession = useCallback((): Promise<string | null> => {
+    if (refreshPromise.current) return refreshPromise.current;
+    const refreshToken = localStorage.getItem('authRefreshToken');
+    if (!refreshToken) return Promise.resolve(null);
+
+    refreshPromise.current = (async () => {
+      try {
+        const response = await fetch(`${API_URL}/auth/refresh`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        });
+        if (!response.ok) {
+          // Only a rejected refresh token ends the session; server errors are retried later
+          if (response.status === 401) {
+            clearSession();
+            router.push('/auth/login');
+          }
+          return null;
+        }
+        const data = await response.json();
+        localStorage.setItem('authRefreshToken', data.refresh_token);
+        storeAccessToken(data.token, data.expires_in);
+        return data.token as string;
+      } catch (error) {
+        console.error("Failed to refresh session:", error);
+        return null;
+      } finally {
+        refreshPromise.current = null;
+      }
+    })();
+    return refreshPromise.current;
+  }, [clearSession, storeAccessToken, router]);
 
   useEffect(() => {
     // Check local storage for token and user data on initial load
     const storedToken = localStorage.getItem('authToken');
     const storedUser = localStorage.getItem('authUser');
     const storedActiveRole = localStorage.getItem('activeRole') as ActiveRole | null;
+    const storedExpiresAt = Number(localStorage.getItem('authTokenExpiresAt')) || null;
 
     let initialUser: User | null = null;
     if (storedToken && storedUser) {
       try {
         initialUser = JSON.parse(storedUser);
+        tokenRef.current = storedToken;
         setToken(storedToken);
+        setExpiresAt(storedExpiresAt);
         setUser(initialUser);
         // Set active role: use stored role if valid, otherwise default to user's primary role
         setActiveRole(storedActiveRole && initialUser?.availableRoles?.includes(storedActiveRole) ? storedActiveRole : initialUser?.role || null);
-        // Optional: Validate token with backend here
       } catch (error) {
         console.error("Failed to parse stored user data:", error);
-        localStorage.removeItem('authToken');
-        localStorage.removeItem('authUser');
-        localStorage.removeItem('activeRole');
+        clearSession();
       }
     }
+    // A token that has expired while the app was closed is refreshed before pages use it
+    if (initialUser && storedExpiresAt && storedExpiresAt - Date.now() < REFRESH_MARGIN_MS) {
+      refreshSession().finally(() => setIsLoading(false));
+      return;
+    }
     setIsLoading(false); // Finish loading after checking storage
-  }, []);
+  }, [clearSession, refreshSession]);
+
+  // Refresh the access token shortly before it expires
+  useEffect(() => {
+    if (!token || !expiresAt) return;
+    const timer = setTimeout(() => { refreshSession(); }, Math.max(expiresAt - Date.now() - REFRESH_MARGIN_MS, 0));
+    return () => clearTimeout(timer);
+  }, [token, expiresAt, refreshSession]);
+
+  const authFetch = useCallback(async (input: string, init: RequestInit = {}) => {
+    const send = (accessToken: string | null) => {
+      const headers = new Headers(init.headers);
+      if (accessToken) headers.set('Authorization', `Bearer ${accessToken}`);
+      return fetch(input, { ...init, headers });
+    };
+    const response = await send(tokenRef.current);
+    if (response.status !== 401) return response;
+    const newToken = await refreshSession();
+    return newToken ? send(newToken) : response;
+  }, [refreshSession]);
 
   // Determine if the user can switch roles
   const canSwitchRole = !!user?.availableRoles && user.availableRoles.length > 1;
@@ -100,47 +203,65 @@ const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   }, [user, activeRole, isLoading, pathname, router]);
 
 
-  const login = (newToken: string, userData: User) => {
+  const login = (newToken: string, userData: User, session: SessionInfo = {}) => {
     // Ensure availableRoles is set, default to just the primary role if not provided
     const roles = userData.availableRoles || [userData.role];
     const userWithRoles = { ...userData, availableRoles: roles };
 
-    const currentActiveRole = localStorage.getItem('activeRole') as ActiveRole | null;
-    // Use stored active role if valid for the new user, otherwise default to primary role
+    // The token carries the active role, so the server's choice wins; profile
+    // updates pass the current token again and keep the current role
+    const currentActiveRole = session.activeRole || localStorage.getItem('activeRole') as ActiveRole | null;
     const newActiveRole = currentActiveRole && roles.includes(currentActiveRole) ? currentActiveRole : userWithRoles.role;
 
-    localStorage.setItem('authToken', newToken);
+    storeAccessToken(newToken, session.expiresIn);
+    if (session.refreshToken) localStorage.setItem('authRefreshToken', session.refreshToken);
     localStorage.setItem('authUser', JSON.stringify(userWithRoles));
     localStorage.setItem('activeRole', newActiveRole); // Store active role
-    setToken(newToken);
     setUser(userWithRoles);
     setActiveRole(newActiveRole);
     // Redirect is handled by useEffect
   };
 
   const logout = () => {
-    localStorage.removeItem('authToken');
-    localStorage.removeItem('authUser');
-    localStorage.removeItem('activeRole');
-    setToken(null);
-    setUser(null);
-    setActiveRole(null);
+    // Revoke the session on the server too, so its refresh token stops working
+    if (tokenRef.current) {
+      fetch(`${API_URL}/auth/logout`, {
+        method: 'POST',
+        headers: { Authorization: `Bearer ${tokenRef.current}` },
+        keepalive: true,
+      }).catch((error) => console.error("Failed to log out on the server:", error));
+    }
+    clearSession();
     router.push('/auth/login'); // Redirect to login after logout
   };
 
-  const switchRole = useCallback(() => {
+  const switchRole = useCallback(async () => {
     if (!user || !canSwitchRole || !activeRole) return;
 
     // Simple toggle between the two roles
     const nextRole = activeRole === 'interviewee' ? 'interviewer' : 'interviewee';
+    if (!user.availableRoles?.includes(nextRole)) return;
 
-    if (user.availableRoles?.includes(nextRole)) {
-       setActiveRole(nextRole);
-       localStorage.setItem('activeRole', nextRole);
-       // Redirect to the new active dashboard after switching
-       router.push(`/dashboard/${nextRole}`);
+    // The server issues a token for the new role and revokes the current one
+    try {
+      const response = await authFetch(`${API_URL}/users/active-role`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ role: nextRole }),
+      });
+      const data = await response.json();
+      if (!response.ok) {
+        throw new Error(data.error || `Failed to switch role: ${response.statusText}`);
+      }
+      storeAccessToken(data.token, data.expires_in);
+      setActiveRole(data.active_role);
+      localStorage.setItem('activeRole', data.active_role);
+      // Redirect to the new active dashboard after switching
+      router.push(`/dashboard/${data.active_role}`);
+    } catch (error) {
+      console.error("Failed to switch role:", error);
     }
-  }, [user, canSwitchRole, activeRole, router]);
+  }, [user, canSwitchRole, activeRole, authFetch, storeAccessToken, router]);
 
   const value = {
     user,
@@ -150,6 +271,7 @@ const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
     canSwitchRole,
     login,
     logout,
+    authFetch,
     switchRole,
   };
 